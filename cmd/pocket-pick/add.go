@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/whitekid/go-utils/log"
+	pocket "github.com/whitekid/pocket-pick/pkg"
+	"github.com/whitekid/pocket-pick/pkg/config"
+)
+
+func init() {
+	var title string
+	var tags []string
+
+	cmd := &cobra.Command{
+		Use:          "add url...",
+		Long:         "add articles; read urls from stdin if url is not given or url is -",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 || (len(args) == 1 && args[0] == "-") {
+				urls, err := readURLs(os.Stdin)
+				if err != nil {
+					return errors.Wrap(err, "read urls from stdin")
+				}
+				args = urls
+			}
+
+			return addArticle(cmd.Context(), title, tags, args...)
+		},
+	}
+	cmd.Flags().StringVarP(&title, "title", "t", "", "title of article")
+	cmd.Flags().StringSliceVar(&tags, "tags", nil, "comma-separated tags of article")
+
+	rootCmd.AddCommand(cmd)
+}
+
+// readURLs read urls line by line; empty lines and lines starting with # are ignored
+func readURLs(r io.Reader) ([]string, error) {
+	var urls []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+
+	return urls, scanner.Err()
+}
+
+func addArticle(ctx context.Context, title string, tags []string, urls ...string) error {
+	api := pocket.NewGetPocketAPI(config.ConsumerKey(), config.AccessToken())
+	for _, url := range urls {
+		article, err := api.Articles.Add(ctx, url, title, tags...)
+		if err != nil {
+			return errors.Wrapf(err, "articles.Add(%s)", url)
+		}
+
+		log.Infof("added %s, %s", article.ItemID, url)
+	}
+
+	return nil
+}
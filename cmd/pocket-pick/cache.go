@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	pocket "github.com/whitekid/pocket-pick/pkg"
+	"github.com/whitekid/pocket-pick/pkg/config"
+)
+
+func init() {
+	cacheCmd := &cobra.Command{
+		Use:  "cache",
+		Long: "manage cache entries of the user of access token on the server of root url; admin token is required",
+	}
+
+	cacheCmd.AddCommand(&cobra.Command{
+		Use:          "list",
+		Long:         "list cache entries",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withUserCache(func(cache *pocket.UserCacheClient) error {
+				entries, err := cache.Entries()
+				if err != nil {
+					return err
+				}
+
+				for _, entry := range entries {
+					fmt.Printf("%s\t%d\n", entry.Name, entry.Size)
+				}
+				return nil
+			})
+		},
+	})
+
+	cacheCmd.AddCommand(&cobra.Command{
+		Use:          "show name",
+		Long:         "show value of cache entry such as favorites, sync/favorites, picks or history",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withUserCache(func(cache *pocket.UserCacheClient) error {
+				value, err := cache.Get(args[0])
+				if err != nil {
+					return err
+				}
+
+				var buf bytes.Buffer
+				if err := json.Indent(&buf, value, "", "  "); err != nil {
+					buf.Reset()
+					buf.Write(value)
+				}
+				buf.WriteByte('\n')
+
+				_, err = buf.WriteTo(os.Stdout)
+				return err
+			})
+		},
+	})
+
+	cacheCmd.AddCommand(&cobra.Command{
+		Use:          "purge [name...]",
+		Long:         "purge cache entries of names, or all entries if names are not given",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withUserCache(func(cache *pocket.UserCacheClient) error {
+				return cache.Purge(args...)
+			})
+		},
+	})
+
+	rootCmd.AddCommand(cacheCmd)
+}
+
+func withUserCache(fn func(cache *pocket.UserCacheClient) error) error {
+	cache, err := pocket.NewUserCacheClient(config.RootURL(), config.AdminToken(), config.AccessToken())
+	if err != nil {
+		return err
+	}
+
+	return fn(cache)
+}
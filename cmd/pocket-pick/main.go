@@ -1,11 +1,37 @@
 package main
 
 import (
+	"context"
 	"os"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/whitekid/go-utils/log"
+	"github.com/whitekid/go-utils/service"
+	pocket "github.com/whitekid/pocket-pick/pkg"
 )
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	if err := rootCmd.ExecuteContext(service.SetupSignal(context.Background(), os.Interrupt, syscall.SIGTERM)); err != nil {
+		if hint := errorHint(err); hint != "" {
+			log.Error(hint)
+		}
 		os.Exit(1)
 	}
 }
+
+// errorHint return helpful message for pocket api errors
+func errorHint(err error) string {
+	switch {
+	case errors.Is(err, pocket.ErrMissingConsumerKey), errors.Is(err, pocket.ErrInvalidConsumerKey):
+		return "check your consumer key; set it with PP_CONSUMER_KEY"
+	case errors.Is(err, pocket.ErrMissingAccessToken), errors.Is(err, pocket.ErrInvalidAccessToken):
+		return "check your access token; set it with PP_ACCESS_TOKEN"
+	case errors.Is(err, pocket.ErrRateLimited):
+		return "pocket api rate limit exceeded; try again later"
+	case errors.Is(err, pocket.ErrServerError):
+		return "pocket server error; try again later"
+	}
+
+	return ""
+}
@@ -23,7 +23,679 @@ var doc = `{
     },
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
-    "paths": {}
+    "paths": {
+        "/articles": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "list favorite articles matched with filters, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "articles"
+                ],
+                "summary": "list favorite articles",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tag, or _untagged_",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "domain including subdomains",
+                        "name": "domain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "unread, archive or all",
+                        "name": "state",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max minutes to read",
+                        "name": "max_minutes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "article, video or image",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "name of preset; saved with filters, or loaded without filters",
+                        "name": "preset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "offset of articles",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max number of articles, all if not given",
+                        "name": "count",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/pocket.articleList"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "articles": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/pocket.articleJSON"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/articles/{item_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "delete, archive, favorite or unfavorite an article",
+                "tags": [
+                    "articles"
+                ],
+                "summary": "modify an article",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "item id",
+                        "name": "item_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/articles/{item_id}/archive": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "delete, archive, favorite or unfavorite an article",
+                "tags": [
+                    "articles"
+                ],
+                "summary": "modify an article",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "item id",
+                        "name": "item_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/articles/{item_id}/favorite": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "delete, archive, favorite or unfavorite an article",
+                "tags": [
+                    "articles"
+                ],
+                "summary": "modify an article",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "item id",
+                        "name": "item_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/articles/{item_id}/unfavorite": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "delete, archive, favorite or unfavorite an article",
+                "tags": [
+                    "articles"
+                ],
+                "summary": "modify an article",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "item id",
+                        "name": "item_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/articles/{item_id}/tags": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "add, remove, replace or clear tags of an article",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "articles"
+                ],
+                "summary": "change tags of an article",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "item id",
+                        "name": "item_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "tags to change",
+                        "name": "tags",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/pocket.tagsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/pick": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "pick an article from favorites with filters and strategy; picks are recorded to history",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pick"
+                ],
+                "summary": "pick an article",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tag, or _untagged_",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "domain including subdomains",
+                        "name": "domain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "unread, archive or all",
+                        "name": "state",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max minutes to read",
+                        "name": "max_minutes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "article, video or image",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "name of preset; saved with filters, or loaded without filters",
+                        "name": "preset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "uniform, oldest, recent, tags or rarely",
+                        "name": "strategy",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/pocket.articleJSON"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/swagger.json": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "docs"
+                ],
+                "summary": "swagger document",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "echo.HTTPError": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "object"
+                }
+            }
+        },
+        "pocket.Author": {
+            "type": "object",
+            "properties": {
+                "author_id": {
+                    "type": "string"
+                },
+                "item_id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "pocket.DomainMetadata": {
+            "type": "object",
+            "properties": {
+                "greyscale_logo": {
+                    "type": "string"
+                },
+                "logo": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "pocket.Image": {
+            "type": "object",
+            "properties": {
+                "caption": {
+                    "type": "string"
+                },
+                "credit": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "string"
+                },
+                "image_id": {
+                    "type": "string"
+                },
+                "item_id": {
+                    "type": "string"
+                },
+                "src": {
+                    "type": "string"
+                },
+                "width": {
+                    "type": "string"
+                }
+            }
+        },
+        "pocket.Tag": {
+            "type": "object",
+            "properties": {
+                "item_id": {
+                    "type": "string"
+                },
+                "tag": {
+                    "type": "string"
+                }
+            }
+        },
+        "pocket.Video": {
+            "type": "object",
+            "properties": {
+                "height": {
+                    "type": "string"
+                },
+                "item_id": {
+                    "type": "string"
+                },
+                "src": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "vid": {
+                    "type": "string"
+                },
+                "video_id": {
+                    "type": "string"
+                },
+                "width": {
+                    "type": "string"
+                }
+            }
+        },
+        "pocket.articleJSON": {
+            "type": "object",
+            "properties": {
+                "authors": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/pocket.Author"
+                    }
+                },
+                "domain_metadata": {
+                    "$ref": "#/definitions/pocket.DomainMetadata"
+                },
+                "excerpt": {
+                    "type": "string"
+                },
+                "favorite": {
+                    "type": "string"
+                },
+                "given_title": {
+                    "type": "string"
+                },
+                "given_url": {
+                    "type": "string"
+                },
+                "has_image": {
+                    "type": "string"
+                },
+                "has_video": {
+                    "type": "string"
+                },
+                "images": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/pocket.Image"
+                    }
+                },
+                "is_article": {
+                    "type": "string"
+                },
+                "is_index": {
+                    "type": "string"
+                },
+                "item_id": {
+                    "type": "string"
+                },
+                "lang": {
+                    "type": "string"
+                },
+                "resolved_id": {
+                    "type": "string"
+                },
+                "resolved_title": {
+                    "type": "string"
+                },
+                "resolved_url": {
+                    "type": "string"
+                },
+                "sort_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/pocket.Tag"
+                    }
+                },
+                "time_added": {
+                    "type": "string"
+                },
+                "time_favorited": {
+                    "type": "string"
+                },
+                "time_read": {
+                    "type": "string"
+                },
+                "time_to_read": {
+                    "type": "string"
+                },
+                "time_updated": {
+                    "type": "string"
+                },
+                "top_image_url": {
+                    "type": "string"
+                },
+                "videos": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/pocket.Video"
+                    }
+                },
+                "word_count": {
+                    "type": "string"
+                }
+            }
+        },
+        "pocket.articleList": {
+            "type": "object",
+            "properties": {
+                "articles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/pocket.articleJSON"
+                    }
+                },
+                "total": {
+                    "description": "number of articles matched",
+                    "type": "integer"
+                }
+            }
+        },
+        "pocket.tagsRequest": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "default is add",
+                    "type": "string",
+                    "enum": [
+                        "add",
+                        "remove",
+                        "replace",
+                        "clear"
+                    ]
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "BearerAuth": {
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
+    }
 }`
 
 type swaggerInfo struct {
@@ -37,12 +709,12 @@ type swaggerInfo struct {
 
 // SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = swaggerInfo{
-	Version:     "",
+	Version:     "1.0",
 	Host:        "",
-	BasePath:    "",
+	BasePath:    "/api/v1",
 	Schemes:     []string{},
-	Title:       "",
-	Description: "",
+	Title:       "pocket-pick API",
+	Description: "random pick favorite articles from getpocket.com",
 }
 
 type s struct{}
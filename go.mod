@@ -4,9 +4,11 @@ go 1.16
 
 require (
 	github.com/alecthomas/template v0.0.0-20190718012654-fb15b899a751
+	github.com/alicebob/miniredis/v2 v2.30.0
 	github.com/allegro/bigcache v1.2.1
-	github.com/allegro/bigcache/v2 v2.2.5 // indirect
-	github.com/allegro/bigcache/v3 v3.0.0
+	github.com/allegro/bigcache/v3 v3.0.2
+	github.com/gomodule/redigo v1.8.4
+	github.com/gorilla/securecookie v1.1.1
 	github.com/gorilla/sessions v1.2.1
 	github.com/labstack/echo-contrib v0.9.0
 	github.com/labstack/echo/v4 v4.2.1
@@ -17,4 +19,6 @@ require (
 	github.com/stretchr/testify v1.7.0
 	github.com/swaggo/swag v1.7.0
 	github.com/whitekid/go-utils v0.0.0-20210210045943-8e9a4bf0b39e
+	go.etcd.io/bbolt v1.3.5
+	golang.org/x/sync v0.2.0
 )
@@ -0,0 +1,306 @@
+package pocket
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/swaggo/swag"
+	"github.com/whitekid/go-utils/log"
+	_ "github.com/whitekid/pocket-pick/docs" // swagger docs of api
+)
+
+// setupAPIRoute setup json api routes under /api/v1
+func (s *pocketService) setupAPIRoute(e *echo.Echo) {
+	e.GET("/api/v1/swagger.json", s.handleGetSwagger)
+
+	api := e.Group("/api/v1")
+	api.GET("/pick", s.handleAPIPick, s.requireAPIToken)
+	api.GET("/articles", s.handleAPIListArticles, s.requireAPIToken)
+
+	// changes require bearer token; session cookie could be sent by cross-site requests
+	api.DELETE("/articles/:item_id", s.handleAPIArticleAction(ActionDelete), s.requireBearerToken)
+	api.POST("/articles/:item_id/archive", s.handleAPIArticleAction(ActionArchive), s.requireBearerToken)
+	api.POST("/articles/:item_id/favorite", s.handleAPIArticleAction(ActionFavorite), s.requireBearerToken)
+	api.POST("/articles/:item_id/unfavorite", s.handleAPIArticleAction(ActionUnfavorite), s.requireBearerToken)
+	api.POST("/articles/:item_id/tags", s.handleAPIArticleTags, s.requireBearerToken)
+}
+
+// requireAPIToken allow requests with access token as bearer token or in session
+func (s *pocketService) requireAPIToken(next echo.HandlerFunc) echo.HandlerFunc {
+	return s.apiTokenAuth(next, true)
+}
+
+// requireBearerToken allow requests with access token as bearer token only
+func (s *pocketService) requireBearerToken(next echo.HandlerFunc) echo.HandlerFunc {
+	return s.apiTokenAuth(next, false)
+}
+
+func (s *pocketService) apiTokenAuth(next echo.HandlerFunc, allowSession bool) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var accessToken string
+		if auth := c.Request().Header.Get(echo.HeaderAuthorization); strings.HasPrefix(auth, "Bearer ") {
+			accessToken = strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+		} else if token, ok := s.session(c).Values[keyAccessToken].(string); ok && allowSession {
+			accessToken = token
+		}
+
+		if accessToken == "" {
+			return echo.NewHTTPError(http.StatusUnauthorized, "access token required")
+		}
+
+		c.Set(keyAccessToken, accessToken)
+		return next(c)
+	}
+}
+
+func apiAccessToken(c echo.Context) string { return c.Get(keyAccessToken).(string) }
+
+// apiError convert pocket api errors to http errors
+func apiError(err error) error {
+	var httpErr *echo.HTTPError
+
+	switch {
+	case errors.As(err, &httpErr):
+		return httpErr
+	case errors.Is(err, ErrInvalidAccessToken), errors.Is(err, ErrMissingAccessToken):
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid access token")
+	case errors.Is(err, ErrActionFailed):
+		return echo.NewHTTPError(http.StatusNotFound, "action failed, article may not exist")
+	case errors.Is(err, ErrRateLimited):
+		return echo.NewHTTPError(http.StatusTooManyRequests, "pocket api rate limited, try again later")
+	case errors.Is(err, ErrServerError):
+		return echo.NewHTTPError(http.StatusBadGateway, "pocket server error")
+	}
+
+	return err
+}
+
+// handleGetSwagger godoc
+// @Summary swagger document
+// @Tags docs
+// @Produce json
+// @Success 200 {object} object
+// @Router /swagger.json [get]
+func (s *pocketService) handleGetSwagger(c echo.Context) error {
+	doc, err := swag.ReadDoc()
+	if err != nil {
+		return err
+	}
+
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSONCharsetUTF8, []byte(doc))
+}
+
+// handleAPIPick godoc
+// @Summary pick an article
+// @Description pick an article from favorites with filters and strategy; picks are recorded to history
+// @Tags pick
+// @Produce json
+// @Param tag query string false "tag, or _untagged_"
+// @Param domain query string false "domain including subdomains"
+// @Param state query string false "unread, archive or all"
+// @Param max_minutes query int false "max minutes to read"
+// @Param type query string false "article, video or image"
+// @Param preset query string false "name of preset; saved with filters, or loaded without filters"
+// @Param strategy query string false "uniform, oldest, recent, tags or rarely"
+// @Success 200 {object} pocket.articleJSON
+// @Failure 400 {object} echo.HTTPError
+// @Failure 401 {object} echo.HTTPError
+// @Failure 404 {object} echo.HTTPError
+// @Security BearerAuth
+// @Router /pick [get]
+func (s *pocketService) handleAPIPick(c echo.Context) error {
+	article, err := s.pick(c, apiAccessToken(c))
+	if err != nil {
+		return apiError(err)
+	}
+
+	return c.JSON(http.StatusOK, article)
+}
+
+// articleList response of article list
+type articleList struct {
+	Total    int       `json:"total"` // number of articles matched
+	Articles []Article `json:"articles"`
+}
+
+// handleAPIListArticles godoc
+// @Summary list favorite articles
+// @Description list favorite articles matched with filters, newest first
+// @Tags articles
+// @Produce json
+// @Param tag query string false "tag, or _untagged_"
+// @Param domain query string false "domain including subdomains"
+// @Param state query string false "unread, archive or all"
+// @Param max_minutes query int false "max minutes to read"
+// @Param type query string false "article, video or image"
+// @Param preset query string false "name of preset; saved with filters, or loaded without filters"
+// @Param offset query int false "offset of articles"
+// @Param count query int false "max number of articles, all if not given"
+// @Success 200 {object} pocket.articleList{articles=[]pocket.articleJSON}
+// @Failure 400 {object} echo.HTTPError
+// @Failure 401 {object} echo.HTTPError
+// @Security BearerAuth
+// @Router /articles [get]
+func (s *pocketService) handleAPIListArticles(c echo.Context) error {
+	offset, err := queryInt(c, "offset")
+	if err != nil {
+		return err
+	}
+
+	count, err := queryInt(c, "count")
+	if err != nil {
+		return err
+	}
+
+	filter, err := s.pickFilter(c, false)
+	if err != nil {
+		return err
+	}
+
+	articles, err := s.favoriteArticles(c.Request().Context(), apiAccessToken(c))
+	if err != nil {
+		return apiError(err)
+	}
+
+	result := articleList{Articles: make([]Article, 0, len(articles))}
+	for _, article := range filter.Filter(articles) {
+		result.Articles = append(result.Articles, article)
+	}
+	sort.Slice(result.Articles, func(i, j int) bool {
+		a, b := result.Articles[i], result.Articles[j]
+		if !a.TimeAdded.Equal(b.TimeAdded) {
+			return a.TimeAdded.After(b.TimeAdded)
+		}
+		return a.ItemID > b.ItemID
+	})
+	result.Total = len(result.Articles)
+
+	if offset > len(result.Articles) {
+		offset = len(result.Articles)
+	}
+	result.Articles = result.Articles[offset:]
+	if count > 0 && count < len(result.Articles) {
+		result.Articles = result.Articles[:count]
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// queryInt parse non-negative integer query parameter; 0 if not given
+func queryInt(c echo.Context, name string) (int, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return 0, nil
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil || i < 0 {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "invalid "+name)
+	}
+
+	return i, nil
+}
+
+// handleAPIArticleAction godoc
+// @Summary modify an article
+// @Description delete, archive, favorite or unfavorite an article
+// @Tags articles
+// @Param item_id path string true "item id"
+// @Success 204
+// @Failure 401 {object} echo.HTTPError
+// @Failure 404 {object} echo.HTTPError
+// @Security BearerAuth
+// @Router /articles/{item_id} [delete]
+// @Router /articles/{item_id}/archive [post]
+// @Router /articles/{item_id}/favorite [post]
+// @Router /articles/{item_id}/unfavorite [post]
+func (s *pocketService) handleAPIArticleAction(action string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		itemID := c.Param("item_id")
+		accessToken := apiAccessToken(c)
+		api := s.newAPI(accessToken).Articles
+		ctx := c.Request().Context()
+
+		var err error
+		switch action {
+		case ActionDelete:
+			err = api.Delete(ctx, itemID)
+		case ActionArchive:
+			err = api.Archive(ctx, itemID)
+		case ActionFavorite:
+			err = api.Favorite(ctx, itemID)
+		case ActionUnfavorite:
+			err = api.Unfavorite(ctx, itemID)
+		}
+
+		if err != nil {
+			log.Errorf("%s %s failed: %s", action, itemID, err)
+			return apiError(err)
+		}
+		s.articleChanged(accessToken, itemID, action)
+
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+// tagsRequest request of tag changes
+type tagsRequest struct {
+	Action string   `json:"action" enums:"add,remove,replace,clear"` // default is add
+	Tags   []string `json:"tags"`
+}
+
+// handleAPIArticleTags godoc
+// @Summary change tags of an article
+// @Description add, remove, replace or clear tags of an article
+// @Tags articles
+// @Accept json
+// @Param item_id path string true "item id"
+// @Param tags body pocket.tagsRequest true "tags to change"
+// @Success 204
+// @Failure 400 {object} echo.HTTPError
+// @Failure 401 {object} echo.HTTPError
+// @Failure 404 {object} echo.HTTPError
+// @Security BearerAuth
+// @Router /articles/{item_id}/tags [post]
+func (s *pocketService) handleAPIArticleTags(c echo.Context) error {
+	var req tagsRequest
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	itemID := c.Param("item_id")
+	accessToken := apiAccessToken(c)
+	api := s.newAPI(accessToken).Articles
+	ctx := c.Request().Context()
+
+	if req.Action != "clear" && len(req.Tags) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "tags required")
+	}
+
+	var action string
+	var err error
+	switch req.Action {
+	case "add", "":
+		action, err = ActionTagsAdd, api.TagsAdd(ctx, itemID, req.Tags...)
+	case "remove":
+		action, err = ActionTagsRemove, api.TagsRemove(ctx, itemID, req.Tags...)
+	case "replace":
+		action, err = ActionTagsReplace, api.TagsReplace(ctx, itemID, req.Tags...)
+	case "clear":
+		action, err = ActionTagsClear, api.TagsClear(ctx, itemID)
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid action: "+req.Action)
+	}
+
+	if err != nil {
+		log.Errorf("tags %s %s failed: %s", req.Action, itemID, err)
+		return apiError(err)
+	}
+	s.articleChanged(accessToken, itemID, action, req.Tags...)
+
+	return c.NoContent(http.StatusNoContent)
+}
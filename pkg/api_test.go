@@ -0,0 +1,282 @@
+package pocket
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/whitekid/go-utils/request"
+	"github.com/whitekid/pocket-pick/pkg/pockettest"
+)
+
+func TestAPIAuth(t *testing.T) {
+	ts, _, teardown := newTestServer()
+	defer teardown()
+
+	resp, err := request.Get("%s/api/v1/pick", ts.URL).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, err = request.Get("%s/api/v1/pick", ts.URL).AuthBearer("invalid-token").Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestAPISessionAuth(t *testing.T) {
+	ts, pocketServer, teardown := newTestServer()
+	defer teardown()
+
+	ids := pocketServer.AddItems(pockettest.Item{GivenURL: "https://golang.org/", Favorite: "1",
+		Tags: map[string]pockettest.Tag{"go": {Tag: "go"}}})
+
+	sess := request.NewSession(nil)
+	followRedirect(t, sess, ts.URL, ts.URL, pocketServer.URL)
+
+	// list with session does not save preset
+	resp, err := sess.Get("%s/api/v1/articles", ts.URL).Param("preset", "go").Param("tag", "go").Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Empty(t, resp.Cookies())
+
+	resp, err = sess.Get("%s/api/v1/articles", ts.URL).Param("preset", "go").Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// changes require bearer token
+	resp, err = sess.Delete("%s/api/v1/articles/%s", ts.URL, ids[0]).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, err = sess.Post("%s/api/v1/articles/%s/archive", ts.URL, ids[0]).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	require.Empty(t, pocketServer.Actions())
+}
+
+func TestAPIPick(t *testing.T) {
+	ts, pocketServer, teardown := newTestServer()
+	defer teardown()
+
+	ids := pocketServer.AddItems(pockettest.Item{GivenURL: "https://golang.org/", Favorite: "1"})
+
+	resp, err := request.Get("%s/api/v1/pick", ts.URL).AuthBearer(pockettest.AccessToken).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var article Article
+	require.NoError(t, resp.JSON(&article))
+	require.Equal(t, ids[0], article.ItemID)
+	require.Equal(t, "https://golang.org/", article.GivenURL)
+
+	resp, err = request.Get("%s/api/v1/pick", ts.URL).Param("tag", "unknown").AuthBearer(pockettest.AccessToken).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = request.Get("%s/api/v1/pick", ts.URL).Param("strategy", "unknown").AuthBearer(pockettest.AccessToken).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestAPIListArticles(t *testing.T) {
+	ts, pocketServer, teardown := newTestServer()
+	defer teardown()
+
+	pocketServer.AddItems(
+		pockettest.Item{GivenURL: "https://golang.org/", Favorite: "1", TimeAdded: "1000",
+			Tags: map[string]pockettest.Tag{"go": {Tag: "go"}}},
+		pockettest.Item{GivenURL: "https://blog.golang.org/", Favorite: "1", TimeAdded: "2000",
+			Tags: map[string]pockettest.Tag{"go": {Tag: "go"}}},
+		pockettest.Item{GivenURL: "https://www.rust-lang.org/", Favorite: "1", TimeAdded: "3000"},
+		pockettest.Item{GivenURL: "https://example.com/", Favorite: "0"},
+	)
+
+	type args struct {
+		params map[string]string
+	}
+	tests := [...]struct {
+		name     string
+		args     args
+		wantCode int
+		wantURLs []string
+		total    int
+	}{
+		{"all", args{nil}, http.StatusOK,
+			[]string{"https://www.rust-lang.org/", "https://blog.golang.org/", "https://golang.org/"}, 3},
+		{"tag", args{map[string]string{"tag": "go"}}, http.StatusOK,
+			[]string{"https://blog.golang.org/", "https://golang.org/"}, 2},
+		{"paging", args{map[string]string{"offset": "1", "count": "1"}}, http.StatusOK,
+			[]string{"https://blog.golang.org/"}, 3},
+		{"offset over", args{map[string]string{"offset": "10"}}, http.StatusOK, []string{}, 3},
+		{"invalid count", args{map[string]string{"count": "-1"}}, http.StatusBadRequest, nil, 0},
+		{"invalid state", args{map[string]string{"state": "unknown"}}, http.StatusBadRequest, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := request.Get("%s/api/v1/articles", ts.URL).Params(tt.args.params).AuthBearer(pockettest.AccessToken).Do()
+			require.NoError(t, err)
+			require.Equal(t, tt.wantCode, resp.StatusCode)
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var list articleList
+			require.NoError(t, resp.JSON(&list))
+			require.Equal(t, tt.total, list.Total)
+
+			urls := make([]string, 0, len(list.Articles))
+			for _, article := range list.Articles {
+				urls = append(urls, article.GivenURL)
+			}
+			require.Equal(t, tt.wantURLs, urls)
+		})
+	}
+}
+
+func TestAPIArticleAction(t *testing.T) {
+	ts, pocketServer, teardown := newTestServer()
+	defer teardown()
+
+	type args struct {
+		method string
+		path   string
+	}
+	tests := [...]struct {
+		name     string
+		args     args
+		wantCode int
+		check    func(t *testing.T, item pockettest.Item)
+	}{
+		{"archive", args{http.MethodPost, "archive"}, http.StatusNoContent,
+			func(t *testing.T, item pockettest.Item) { require.Equal(t, "1", item.Status) }},
+		{"unfavorite", args{http.MethodPost, "unfavorite"}, http.StatusNoContent,
+			func(t *testing.T, item pockettest.Item) { require.Equal(t, "0", item.Favorite) }},
+		{"favorite", args{http.MethodPost, "favorite"}, http.StatusNoContent,
+			func(t *testing.T, item pockettest.Item) { require.Equal(t, "1", item.Favorite) }},
+		{"delete", args{http.MethodDelete, ""}, http.StatusNoContent,
+			func(t *testing.T, item pockettest.Item) { require.Equal(t, "2", item.Status) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := pocketServer.AddItems(pockettest.Item{GivenURL: "https://golang.org/", Favorite: "0"})
+			if tt.args.path == "unfavorite" {
+				ids = pocketServer.AddItems(pockettest.Item{GivenURL: "https://golang.org/", Favorite: "1"})
+			}
+
+			url := strings.TrimSuffix(ts.URL+"/api/v1/articles/"+ids[0]+"/"+tt.args.path, "/")
+			resp, err := request.New(tt.args.method, url).AuthBearer(pockettest.AccessToken).Do()
+			require.NoError(t, err)
+			require.Equal(t, tt.wantCode, resp.StatusCode)
+
+			item, ok := pocketServer.Item(ids[0])
+			require.True(t, ok)
+			tt.check(t, item)
+		})
+	}
+
+	resp, err := request.Post("%s/api/v1/articles/unknown/archive", ts.URL).AuthBearer(pockettest.AccessToken).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestAPIArticleTags(t *testing.T) {
+	ts, pocketServer, teardown := newTestServer()
+	defer teardown()
+
+	ids := pocketServer.AddItems(pockettest.Item{GivenURL: "https://golang.org/", Favorite: "1",
+		Tags: map[string]pockettest.Tag{"go": {Tag: "go"}}})
+
+	type args struct {
+		itemID string
+		req    tagsRequest
+	}
+	tests := [...]struct {
+		name     string
+		args     args
+		wantCode int
+		wantTags []string
+	}{
+		{"add", args{ids[0], tagsRequest{Tags: []string{"lang"}}}, http.StatusNoContent, []string{"go", "lang"}},
+		{"remove", args{ids[0], tagsRequest{Action: "remove", Tags: []string{"go"}}}, http.StatusNoContent, []string{"lang"}},
+		{"replace", args{ids[0], tagsRequest{Action: "replace", Tags: []string{"golang"}}}, http.StatusNoContent, []string{"golang"}},
+		{"clear", args{ids[0], tagsRequest{Action: "clear"}}, http.StatusNoContent, []string{}},
+		{"tags required", args{ids[0], tagsRequest{Action: "add"}}, http.StatusBadRequest, []string{}},
+		{"invalid action", args{ids[0], tagsRequest{Action: "rename", Tags: []string{"go"}}}, http.StatusBadRequest, []string{}},
+		{"not found", args{"unknown", tagsRequest{Tags: []string{"go"}}}, http.StatusNotFound, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := request.Post("%s/api/v1/articles/%s/tags", ts.URL, tt.args.itemID).
+				JSON(tt.args.req).AuthBearer(pockettest.AccessToken).Do()
+			require.NoError(t, err)
+			require.Equal(t, tt.wantCode, resp.StatusCode)
+
+			item, ok := pocketServer.Item(ids[0])
+			require.True(t, ok)
+			tags := []string{}
+			for tag := range item.Tags {
+				tags = append(tags, tag)
+			}
+			require.ElementsMatch(t, tt.wantTags, tags)
+		})
+	}
+}
+
+func TestAPISwagger(t *testing.T) {
+	ts, _, teardown := newTestServer()
+	defer teardown()
+
+	resp, err := request.Get("%s/api/v1/swagger.json", ts.URL).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var doc struct {
+		BasePath string                 `json:"basePath"`
+		Paths    map[string]interface{} `json:"paths"`
+	}
+	require.NoError(t, resp.JSON(&doc))
+	require.Equal(t, "/api/v1", doc.BasePath)
+	require.Contains(t, doc.Paths, "/pick")
+	require.Contains(t, doc.Paths, "/articles")
+}
+
+func TestAPIArticleChanged(t *testing.T) {
+	ts, pocketServer, teardown := newTestServer()
+	defer teardown()
+
+	ids := pocketServer.AddItems(
+		pockettest.Item{GivenURL: "https://golang.org/", Favorite: "1"},
+		pockettest.Item{GivenURL: "https://www.rust-lang.org/", Favorite: "1"},
+	)
+
+	list := func() map[string]Article {
+		resp, err := request.Get("%s/api/v1/articles", ts.URL).AuthBearer(pockettest.AccessToken).Do()
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var list articleList
+		require.NoError(t, resp.JSON(&list))
+
+		articles := make(map[string]Article)
+		for _, article := range list.Articles {
+			articles[article.ItemID] = article
+		}
+		return articles
+	}
+	require.Equal(t, 2, len(list()))
+
+	// changes are visible without waiting for refresh
+	resp, err := request.Delete("%s/api/v1/articles/%s", ts.URL, ids[0]).AuthBearer(pockettest.AccessToken).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = request.Post("%s/api/v1/articles/%s/tags", ts.URL, ids[1]).
+		JSON(tagsRequest{Tags: []string{"rust"}}).AuthBearer(pockettest.AccessToken).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	articles := list()
+	require.NotContains(t, articles, ids[0])
+	article := articles[ids[1]]
+	require.True(t, article.HasTag("rust"))
+}
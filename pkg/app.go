@@ -1,15 +1,20 @@
 package pocket
 
 import (
-	"bytes"
 	"context"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
+	"expvar"
 	"fmt"
-	"math/rand"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/gorilla/securecookie"
 	"github.com/gorilla/sessions"
 	"github.com/labstack/echo-contrib/session"
 	"github.com/labstack/echo/v4"
@@ -18,54 +23,159 @@ import (
 	"github.com/whitekid/go-utils/log"
 	"github.com/whitekid/go-utils/service"
 	"github.com/whitekid/pocket-pick/pkg/config"
+	"golang.org/x/sync/singleflight"
 )
 
 const (
 	keyRequestToken = "REQUEST_TOKEN"
 	keyAccessToken  = "ACCESS_TOKEN"
+	keyOAuthState   = "OAUTH_STATE"
+	keyPickPresets  = "PICK_PRESETS"
+	keyCSRFToken    = "CSRF_TOKEN"
 )
 
 // New return pocket-pick service object
 // implements service interface
+//
+// @title pocket-pick API
+// @version 1.0
+// @description random pick favorite articles from getpocket.com
+// @BasePath /api/v1
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
 func New() service.Interface {
 	rootURL := config.RootURL()
 	if rootURL == "" {
 		panic("ROOT_URL required")
 	}
 
-	return &pocketService{
-		cache:   newBigCache(),
-		rootURL: rootURL,
+	sessionStore, err := newSessionStoreFromConfig()
+	if err != nil {
+		panic(err)
 	}
+
+	tagWeights, err := parseTagWeights(config.PickTagWeights())
+	if err != nil {
+		panic(err)
+	}
+
+	if _, err := newPicker(config.PickStrategy(), pickState{}); err != nil {
+		panic(err)
+	}
+
+	history, err := newHistoryPolicy(config.PickHistory(), config.PickHistoryWindow(), config.PickHistorySize())
+	if err != nil {
+		panic(err)
+	}
+
+	renderer, err := newTemplateRenderer()
+	if err != nil {
+		panic(err)
+	}
+
+	cache, err := newCacheFromConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	s := &pocketService{
+		renderer:     renderer,
+		preview:      config.Preview(),
+		history:      history,
+		pickStrategy: config.PickStrategy(),
+		tagWeights:   tagWeights,
+		cache:        cache,
+		sessionStore: sessionStore,
+		favorites:    newFavoriteSyncer(cache, config.CacheEvictionTimeout()+config.CacheStaleTimeout()),
+		rootURL:      rootURL,
+		consumerKey:  config.ConsumerKey(),
+		apiOptions:   []APIOption{WithRateLimits(NewRateLimits())},
+		adminToken:   config.AdminToken(),
+	}
+	s.vars = newServiceVars(s)
+
+	return s
 }
 
 type pocketService struct {
-	rootURL string
-	cache   cacher // for api cache
+	rootURL           string
+	cache             cacher             // for api cache
+	favorites         *syncer            // sync favorite articles with cache
+	refreshing        singleflight.Group // refresh of favorites of each user
+	refreshes         sync.WaitGroup     // refreshes of favorites in background
+	favoritesLocks    userLocks          // changes of cached favorites of each user are serialized
+	pickLocks         userLocks          // records of picks of each user are serialized
+	pendingChanges    pendingChanges     // changes of articles while refreshing favorites
+	favoritesCounters favoritesCounters  // statistics of favorites refreshes
+	sessionStore      sessions.Store     // store of user sessions
+	pickStrategy      string             // default pick strategy
+	tagWeights        map[string]float64 // tag weights for tags strategy
+	history           historyPolicy      // no-repeat policy of picks
+	renderer          *templateRenderer  // html pages
+	preview           bool               // show preview page before redirecting to the article
+	consumerKey       string             // getpocket consumer key
+	apiOptions        []APIOption        // options for pocket api; rate limits are shared between users
+	adminToken        string             // bearer token for debug routes
+	vars              *expvar.Map        // metrics of the service
 }
 
 // Serve serve the main service
+// when ctx is done, stop accepting requests and wait for in-flight requests and refreshes until shutdown timeout
 func (s *pocketService) Serve(ctx context.Context, args ...string) error {
 	e := s.setupRoute()
 
-	return e.Start(config.BindAddr())
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(errCh)
+		if err := e.Start(config.BindAddr()); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		s.close()
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Infof("shutting down, waiting for requests up to %s", config.ShutdownTimeout())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout())
+	defer cancel()
+
+	err := e.Shutdown(shutdownCtx)
+	if werr := s.waitRefreshes(shutdownCtx); werr != nil && err == nil {
+		err = werr
+	}
+	if cerr := s.close(); cerr != nil && err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// close release session store and cache
+func (s *pocketService) close() error {
+	if closer, ok := s.sessionStore.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			return errors.Wrap(err, "close session store")
+		}
+	}
+
+	return errors.Wrap(s.cache.Close(), "close cache")
 }
 
 func (s *pocketService) setupRoute() *echo.Echo {
 	e := echo.New()
+	e.Renderer = s.renderer
 
 	loggerConfig := middleware.DefaultLoggerConfig
 	e.Use(middleware.LoggerWithConfig(loggerConfig))
-	e.Use(session.Middleware(sessions.NewCookieStore([]byte("secret"))),
+	e.Use(session.Middleware(s.sessionStore),
 		func(next echo.HandlerFunc) echo.HandlerFunc {
 			return func(c echo.Context) error {
 				sess, _ := session.Get("pocket-pick-session", c)
-				sess.Options = &sessions.Options{
-					Path:     "/",
-					MaxAge:   86400,
-					HttpOnly: true,
-				}
-
 				c.Set("session", sess)
 				return next(c)
 			}
@@ -73,12 +183,56 @@ func (s *pocketService) setupRoute() *echo.Echo {
 
 	e.GET("/", s.handleGetIndex)
 	e.GET("/auth", s.handleGetAuth)
-	e.GET("/article/:item_id", s.handleGetArticle) // TODO 원래는 DELETE로 해야하는데, 귀찮아서..
+	e.POST("/article/:item_id/:action", s.handlePostArticleAction)
 	e.GET("/sessions", s.handleGetSession)
+	e.GET("/logout", s.handleGetLogout)
+	e.GET("/history", s.handleGetHistory)
+
+	s.setupAPIRoute(e)
+	s.setupDebugRoute(e)
 
 	return e
 }
 
+// newAPI create pocket api with service options
+func (s *pocketService) newAPI(accessToken string) *GetPocketAPI {
+	return NewGetPocketAPI(s.consumerKey, accessToken, s.apiOptions...)
+}
+
+// favoritesCacheKey cache key of favorite articles of the user
+func favoritesCacheKey(accessToken string) string {
+	return fmt.Sprintf("%s/favorites", accessToken)
+}
+
+// favoritesSyncKey cache key of favorite sync state of the user
+func favoritesSyncKey(accessToken string) string {
+	return fmt.Sprintf("%s/sync/favorites", accessToken)
+}
+
+// pickCountsKey cache key of pick counts of the user
+func pickCountsKey(accessToken string) string {
+	return fmt.Sprintf("%s/picks", accessToken)
+}
+
+// purgeUserCache remove cache entries of the user
+func (s *pocketService) purgeUserCache(accessToken string) {
+	for _, key := range userCacheKeys(accessToken) {
+		if err := s.cache.Delete([]byte(key)); err != nil {
+			log.Errorf("delete cache %s failed: %s", key, err)
+		}
+	}
+}
+
+// renderMessage render simple html page with a link to root
+func (s *pocketService) renderMessage(c echo.Context, code int, title, message, link string) error {
+	return c.Render(code, "message.html", map[string]string{
+		"Title":   title,
+		"Message": message,
+		"Link":    link,
+		"RootURL": s.rootURL,
+	})
+}
+
 func (s *pocketService) session(c echo.Context) *sessions.Session {
 	return c.Get("session").(*sessions.Session)
 }
@@ -109,15 +263,7 @@ func (s *pocketService) handleGetIndex(c echo.Context) error {
 
 	// if not token, try to authorize
 	if _, exists := sess.Values[keyRequestToken]; !exists {
-		requestToken, authorizedURL, err := NewGetPocketAPI(config.ConsumerKey(), "").AuthorizedURL(fmt.Sprintf("%s/auth", s.rootURL))
-		if err != nil {
-			return errors.Wrapf(err, "authorize failed")
-		}
-
-		sess.Values[keyRequestToken] = requestToken
-		log.Infof("save requestToken to session: %s", requestToken)
-		sess.Save(c.Request(), c.Response())
-		return c.Redirect(http.StatusFound, authorizedURL)
+		return s.authorize(c)
 	}
 
 	if _, exists := sess.Values[keyAccessToken]; !exists {
@@ -129,51 +275,72 @@ func (s *pocketService) handleGetIndex(c echo.Context) error {
 	accessToken := sess.Values[keyAccessToken].(string)
 	log.Debugf("accessToken acquired, get random favorite pick: %s", accessToken)
 
-	key := fmt.Sprintf("%s/favorites", accessToken)
-	api := NewGetPocketAPI(config.ConsumerKey(), accessToken)
+	article, err := s.pick(c, accessToken)
+	if err != nil {
+		return s.handleAPIError(c, err)
+	}
 
-	data, exists := s.cache.Get([]byte(key))
-	var articleList map[string]Article
-	if !exists {
-		var err error
-		articleList, err = api.Articles.Get(WithFavorate(Favorited))
-		if err != nil {
-			return errors.Wrap(err, "get favorite artcles failed")
-		}
-		log.Debugf("you have %d articles", len(articleList))
+	if s.showPreview(c) {
+		return s.renderPreview(c, article)
+	}
 
-		// write to cache
-		buf, err := json.Marshal(articleList)
-		if err != nil {
-			return errors.Wrap(err, "json encode failed")
-		}
-		s.cache.Set([]byte(key), buf, withTTL(config.CacheEvictionTimeout()))
-	} else {
-		log.Debug("load articles from cache")
+	url := readURL(article)
+	// log.Infof("move to %s, resolved: %s", url, article.ResolvedURL)
+	return c.Redirect(http.StatusFound, url)
+}
 
-		articleList = make(map[string]Article)
-		buf := bytes.NewBuffer(data)
-		if err := json.NewDecoder(buf).Decode(&articleList); err != nil {
-			return errors.Wrap(err, "json decode failed")
-		}
+// pick pick an article from favorites of the user with filter and strategy of query
+func (s *pocketService) pick(c echo.Context, accessToken string) (Article, error) {
+	filter, err := s.pickFilter(c, true)
+	if err != nil {
+		return Article{}, err
+	}
+
+	strategy := c.QueryParam("strategy")
+	if strategy == "" {
+		strategy = s.pickStrategy
 	}
 
-	// random pick from articles
-	pick := rand.Intn(len(articleList))
+	// counts and history are updated by the pick, so concurrent picks of the user should not load the same ones
+	unlock := s.pickLocks.lock(accessToken)
+	defer unlock()
 
-	selected := ""
-	i := 0
-	for k := range articleList {
-		if i == pick {
-			selected = k
-			break
-		}
-		i++
+	now := time.Now()
+	counts := s.pickCounts(accessToken)
+	picker, err := newPicker(strategy, pickState{now: now, counts: counts, tagWeights: s.tagWeights})
+	if err != nil {
+		return Article{}, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	articles, err := s.favoriteArticles(c.Request().Context(), accessToken)
+	if err != nil {
+		return Article{}, err
+	}
+
+	if len(articles) == 0 {
+		return Article{}, echo.NewHTTPError(http.StatusNotFound, "no favorite articles")
 	}
 
-	article := articleList[selected]
+	articleList := filter.Filter(articles)
+	if len(articleList) == 0 {
+		return Article{}, echo.NewHTTPError(http.StatusNotFound, "no favorite articles matched")
+	}
+
+	history := s.loadHistory(accessToken)
+	article, ok := picker.Pick(s.history.candidates(history, articleList, now))
+	if !ok {
+		return Article{}, echo.NewHTTPError(http.StatusNotFound, "no favorite articles matched")
+	}
 	log.Debugf("article: %+v", article)
+	s.recordPick(accessToken, article.ItemID, articles)
+	s.history.record(history, article.ItemID, now)
+	s.saveHistory(accessToken, history)
 
+	return article, nil
+}
+
+// readURL url to read article in pocket
+func readURL(article Article) string {
 	url := fmt.Sprintf("https://app.getpocket.com/read/%s", article.ItemID)
 	for _, u := range []string{"blog.naver.com"} {
 		if strings.Contains(url, u) {
@@ -188,12 +355,74 @@ func (s *pocketService) handleGetIndex(c echo.Context) error {
 	// x 안보이는 것은... item_id, resolved_id가 같다?
 	//
 	// IsArticle이 뭔 의미인지..
-	// if article.IsArticle == "1" {
+	// if article.IsArticle {
 	// 	url = article.ResolvedURL
 	// }
 
-	// log.Infof("move to %s, resolved: %s", url, article.ResolvedURL)
-	return c.Redirect(http.StatusFound, url)
+	return url
+}
+
+// pickFilter return filter of query parameters
+// with preset parameter and savePreset, the filter is saved as preset in session; preset only loads the saved filter
+func (s *pocketService) pickFilter(c echo.Context, savePreset bool) (pickFilter, error) {
+	filter, err := parsePickFilter(c.QueryParams())
+	if err != nil {
+		return filter, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	name := c.QueryParam("preset")
+	if name == "" {
+		return filter, nil
+	}
+
+	sess := s.session(c)
+	presets := make(map[string]pickFilter)
+	if data, ok := sess.Values[keyPickPresets].(string); ok {
+		if err := json.Unmarshal([]byte(data), &presets); err != nil {
+			log.Errorf("invalid presets in session: %s", err)
+		}
+	}
+
+	if filter.IsZero() {
+		preset, ok := presets[name]
+		if !ok {
+			return filter, echo.NewHTTPError(http.StatusNotFound, "preset not found")
+		}
+		return preset, nil
+	}
+
+	if !savePreset {
+		return filter, nil
+	}
+
+	presets[name] = filter
+	buf, err := json.Marshal(presets)
+	if err != nil {
+		return filter, errors.Wrap(err, "json encode failed")
+	}
+	sess.Values[keyPickPresets] = string(buf)
+	sess.Save(c.Request(), c.Response())
+
+	return filter, nil
+}
+
+// authorize get request token and redirect to pocket to authorize it
+// state is passed with redirect uri and checked on /auth callback to prevent csrf
+func (s *pocketService) authorize(c echo.Context) error {
+	state := hex.EncodeToString(securecookie.GenerateRandomKey(16))
+	redirectURI := fmt.Sprintf("%s/auth?state=%s", s.rootURL, state)
+
+	requestToken, authorizedURL, err := s.newAPI("").AuthorizedURL(c.Request().Context(), redirectURI)
+	if err != nil {
+		return s.handleAPIError(c, errors.Wrapf(err, "authorize failed"))
+	}
+
+	sess := s.session(c)
+	sess.Values[keyRequestToken] = requestToken
+	sess.Values[keyOAuthState] = state
+	log.Infof("save requestToken to session: %s", requestToken)
+	sess.Save(c.Request(), c.Response())
+	return c.Redirect(http.StatusFound, authorizedURL)
 }
 
 func (s *pocketService) handleGetAuth(c echo.Context) (err error) {
@@ -205,21 +434,27 @@ func (s *pocketService) handleGetAuth(c echo.Context) (err error) {
 
 	requestToken := sess.Values[keyRequestToken].(string)
 	if _, exists := sess.Values[keyAccessToken]; !exists {
-		accessToken, _, err := NewGetPocketAPI(config.ConsumerKey(), "").NewAccessToken(requestToken)
+		state, _ := sess.Values[keyOAuthState].(string)
+		if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(c.QueryParam("state"))) != 1 {
+			log.Errorf("oauth state mismatch")
+			s.clearAuthorization(c)
+			return echo.NewHTTPError(http.StatusForbidden, "invalid oauth state")
+		}
+
+		accessToken, _, err := s.newAPI("").NewAccessToken(c.Request().Context(), requestToken)
 		if err != nil {
 			log.Errorf("fail to get access token: %s", err)
-			return err
+			return s.handleAPIError(c, err)
 		}
 
 		if accessToken == "" {
-			delete(sess.Values, keyAccessToken)
-			sess.Save(c.Request(), c.Response())
-
-			return c.Redirect(http.StatusFound, s.rootURL)
+			s.clearAuthorization(c)
+			return s.renderRejected(c)
 		}
 
 		log.Debugf("get accessToken %s", accessToken)
 		sess.Values[keyAccessToken] = accessToken
+		delete(sess.Values, keyOAuthState)
 		sess.Save(c.Request(), c.Response())
 	}
 
@@ -227,36 +462,69 @@ func (s *pocketService) handleGetAuth(c echo.Context) (err error) {
 	return c.Redirect(http.StatusFound, s.rootURL)
 }
 
-func (s *pocketService) requireAccessToken(c echo.Context, token *string) error {
-	sess := s.session(c)
-
-	if _, exists := sess.Values[keyAccessToken]; !exists {
+// handleAPIError react to pocket api errors: authorize again if token is not valid, or return proper http error
+func (s *pocketService) handleAPIError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, ErrInvalidAccessToken), errors.Is(err, ErrMissingAccessToken):
+		sess := s.session(c)
+		delete(sess.Values, keyAccessToken)
 		delete(sess.Values, keyRequestToken)
 		sess.Save(c.Request(), c.Response())
-		return fmt.Errorf("access token not found")
+		return c.Redirect(http.StatusFound, s.rootURL)
+
+	case errors.Is(err, ErrUserRejectedCode), errors.Is(err, ErrAlreadyUsedCode), errors.Is(err, ErrCodeNotFound):
+		s.clearAuthorization(c)
+		return s.renderRejected(c)
+
+	case errors.Is(err, ErrRateLimited):
+		return echo.NewHTTPError(http.StatusTooManyRequests, "pocket api rate limited, try again later")
+
+	case errors.Is(err, ErrServerError):
+		return echo.NewHTTPError(http.StatusBadGateway, "pocket server error")
 	}
 
-	*token = sess.Values[keyAccessToken].(string)
-	return nil
+	return err
 }
 
-// remove given article
-func (s *pocketService) handleGetArticle(c echo.Context) error {
-	itemID := c.Param("item_id")
-	if itemID == "" {
-		return echo.NewHTTPError(http.StatusBadRequest, "ItemID missed")
-	}
+// clearAuthorization remove in-progress authorization from session so that next visit starts it again
+func (s *pocketService) clearAuthorization(c echo.Context) {
+	sess := s.session(c)
+	delete(sess.Values, keyRequestToken)
+	delete(sess.Values, keyOAuthState)
+	sess.Save(c.Request(), c.Response())
+}
 
-	var accessToken string
+// renderRejected explain rejected authorization instead of redirecting to authorize again
+func (s *pocketService) renderRejected(c echo.Context) error {
+	return s.renderMessage(c, http.StatusForbidden, "Authorization rejected",
+		"pocket-pick was not authorized to access your Pocket account.", "Try again")
+}
 
-	if err := s.requireAccessToken(c, &accessToken); err != nil {
-		return c.Redirect(http.StatusFound, s.rootURL)
+// handleGetLogout remove session and cache of the user
+func (s *pocketService) handleGetLogout(c echo.Context) error {
+	sess := s.session(c)
+	if accessToken, ok := sess.Values[keyAccessToken].(string); ok {
+		s.purgeUserCache(accessToken)
 	}
 
-	if err := NewGetPocketAPI(config.ConsumerKey(), accessToken).Articles.Delete(itemID); err != nil {
-		log.Errorf("failed: %s", err)
-		return err
+	for k := range sess.Values {
+		delete(sess.Values, k)
+	}
+	sess.Options.MaxAge = -1
+	sess.Save(c.Request(), c.Response())
+
+	return s.renderMessage(c, http.StatusOK, "Signed out", "You are signed out of pocket-pick.", "Sign in")
+}
+
+func (s *pocketService) requireAccessToken(c echo.Context, token *string) error {
+	sess := s.session(c)
+
+	if _, exists := sess.Values[keyAccessToken]; !exists {
+		delete(sess.Values, keyRequestToken)
+		sess.Save(c.Request(), c.Response())
+		return fmt.Errorf("access token not found")
 	}
 
+	*token = sess.Values[keyAccessToken].(string)
 	return nil
 }
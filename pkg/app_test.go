@@ -1,28 +1,49 @@
 package pocket
 
 import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/allegro/bigcache"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/require"
 	"github.com/whitekid/go-utils/request"
+	"github.com/whitekid/pocket-pick/pkg/pockettest"
 )
 
-func newTestServer() (*httptest.Server, func()) {
+// newTestServer start pocket-pick service with fake pocket server
+// setups are applied to service before setup routes
+func newTestServer(setups ...func(s *pocketService)) (*httptest.Server, *pockettest.Server, func()) {
+	pocketServer := pockettest.NewServer()
+
 	s := New().(*pocketService)
+	s.consumerKey = pockettest.ConsumerKey
+	s.apiOptions = testAPIOptions(pocketServer)
+	for _, setup := range setups {
+		setup(s)
+	}
 	e := s.setupRoute()
 
 	ts := httptest.NewServer(e)
-	return ts, func() { ts.Close() }
+	s.rootURL = ts.URL
+	return ts, pocketServer, func() {
+		ts.Close()
+		pocketServer.Close()
+		s.close()
+	}
 }
 
 func TestSession(t *testing.T) {
-	ts, teardown := newTestServer()
+	ts, _, teardown := newTestServer()
 	defer teardown()
 
 	sess := request.NewSession(nil)
@@ -40,18 +61,74 @@ func TestSession(t *testing.T) {
 }
 
 func TestIndex(t *testing.T) {
-	ts, teardown := newTestServer()
+	ts, pocketServer, teardown := newTestServer()
 	defer teardown()
 
 	// check if redirect to authorize url
 	resp, err := request.Get("%s", ts.URL).FollowRedirect(false).Do()
 	require.NoError(t, err)
 	require.Equal(t, http.StatusFound, resp.StatusCode)
-	require.True(t, strings.HasPrefix(resp.Header.Get("Location"), "https://getpocket.com/auth/authorize?request_token="), resp.Header.Get("Location"))
+	require.True(t, strings.HasPrefix(resp.Header.Get("Location"), pocketServer.AuthorizeURL()+"?request_token="), resp.Header.Get("Location"))
+}
+
+// follow redirects until it leaves given servers
+func followRedirect(t *testing.T, sess request.Interface, url string, hosts ...string) *request.Response {
+	for i := 0; i < 10; i++ {
+		resp, err := sess.Get(url).FollowRedirect(false).Do()
+		require.NoError(t, err)
+
+		if resp.StatusCode != http.StatusFound {
+			return resp
+		}
+
+		url = resp.Header.Get("Location")
+		internal := false
+		for _, host := range hosts {
+			if strings.HasPrefix(url, host) {
+				internal = true
+			}
+		}
+		if !internal {
+			return resp
+		}
+	}
+
+	require.Fail(t, "too many redirects")
+	return nil
 }
 
 func TestAuth(t *testing.T) {
-	panic("Not Implemented")
+	ts, pocketServer, teardown := newTestServer()
+	defer teardown()
+
+	ids := pocketServer.AddItems(pockettest.Item{
+		GivenURL:   "https://blog.golang.org/",
+		GivenTitle: "The Go Blog",
+		Favorite:   "1",
+	})
+
+	sess := request.NewSession(nil)
+	resp := followRedirect(t, sess, ts.URL, ts.URL, pocketServer.URL)
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	require.Equal(t, "https://app.getpocket.com/read/"+ids[0], resp.Header.Get("Location"))
+}
+
+func TestGetArticleNotDelete(t *testing.T) {
+	ts, pocketServer, teardown := newTestServer()
+	defer teardown()
+
+	ids := pocketServer.AddItems(pockettest.Item{GivenURL: "https://blog.golang.org/", Favorite: "1"})
+
+	sess := request.NewSession(nil)
+	followRedirect(t, sess, ts.URL, ts.URL, pocketServer.URL)
+
+	// articles are deleted only by DELETE of api, not by links
+	resp, err := sess.Get("%s/article/%s", ts.URL, ids[0]).FollowRedirect(false).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	_, exists := pocketServer.Item(ids[0])
+	require.True(t, exists)
 }
 
 func TestCache(t *testing.T) {
@@ -72,3 +149,334 @@ func TestCache(t *testing.T) {
 		require.NotEqual(t, []byte("world"), value)
 	}
 }
+
+func TestServeShutdown(t *testing.T) {
+	pocketServer := pockettest.NewServer()
+	defer pocketServer.Close()
+	// keep a request in flight while shutting down
+	pocketServer.InjectFault(pockettest.Fault{Path: "/v3/oauth/request", Delay: 300 * time.Millisecond})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	viper.Set("bind_addr", addr)
+	defer viper.Set("bind_addr", nil)
+
+	s := New().(*pocketService)
+	s.consumerKey = pockettest.ConsumerKey
+	s.apiOptions = testAPIOptions(pocketServer)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(ctx) }()
+
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}, time.Second, 10*time.Millisecond)
+
+	inflight := make(chan int, 1)
+	go func() {
+		resp, err := request.Get("http://%s/", addr).FollowRedirect(false).Do()
+		if err != nil {
+			inflight <- 0
+			return
+		}
+		inflight <- resp.StatusCode
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	require.Equal(t, http.StatusFound, <-inflight)
+	require.NoError(t, <-served)
+
+	_, err = net.Dial("tcp", addr)
+	require.Error(t, err)
+}
+
+func TestServeShutdownRefreshing(t *testing.T) {
+	pocketServer := pockettest.NewServer()
+	defer pocketServer.Close()
+	ids := pocketServer.AddItems(pockettest.Item{GivenURL: "https://golang.org/", Favorite: "1"})
+	pocketServer.InjectFault(pockettest.Fault{Path: "/v3/get", Delay: 300 * time.Millisecond, Times: 1})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	path := filepath.Join(t.TempDir(), "cache.db")
+	viper.Set("bind_addr", addr)
+	viper.Set("cache_backend", CacheBolt)
+	viper.Set("cache_path", path)
+	defer func() {
+		viper.Set("bind_addr", nil)
+		viper.Set("cache_backend", nil)
+		viper.Set("cache_path", nil)
+	}()
+
+	s := New().(*pocketService)
+	s.consumerKey = pockettest.ConsumerKey
+	s.apiOptions = testAPIOptions(pocketServer)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(ctx) }()
+
+	// stale articles are returned while refreshing in background
+	setStaleFavorites(t, s, pockettest.AccessToken, 2*time.Hour, map[string]Article{"stale": {ItemID: "stale"}})
+	_, err = s.favoriteArticles(context.Background(), pockettest.AccessToken)
+	require.NoError(t, err)
+
+	cancel()
+	require.NoError(t, <-served)
+
+	// refresh is saved before the cache is closed
+	cache, err := newCache(CacheBolt, 0, path, "")
+	require.NoError(t, err)
+	defer cache.Close()
+
+	data, exists := cache.Get([]byte(favoritesCacheKey(pockettest.AccessToken)))
+	require.True(t, exists)
+	var entry favoritesEntry
+	require.NoError(t, json.Unmarshal(data, &entry))
+	require.True(t, entry.fresh(time.Now()))
+	require.Contains(t, entry.Articles, ids[0])
+}
+
+func TestAuthState(t *testing.T) {
+	ts, pocketServer, teardown := newTestServer()
+	defer teardown()
+
+	sess := request.NewSession(nil)
+	resp, err := sess.Get(ts.URL).FollowRedirect(false).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	require.True(t, strings.HasPrefix(resp.Header.Get("Location"), pocketServer.AuthorizeURL()))
+
+	// pocket redirects to callback with state
+	resp, err = sess.Get(resp.Header.Get("Location")).FollowRedirect(false).Do()
+	require.NoError(t, err)
+	callback := resp.Header.Get("Location")
+	require.True(t, strings.HasPrefix(callback, ts.URL+"/auth?state="), callback)
+
+	// forged callback
+	resp, err = sess.Get(ts.URL + "/auth?state=forged").FollowRedirect(false).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// authorization is cleared, so valid callback does not get access token
+	resp, err = sess.Get(callback).FollowRedirect(false).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	require.Equal(t, ts.URL, resp.Header.Get("Location"))
+	require.Equal(t, 0, pocketServer.RequestCount("/v3/oauth/authorize"))
+}
+
+func TestAuthRejected(t *testing.T) {
+	ts, pocketServer, teardown := newTestServer()
+	defer teardown()
+
+	ids := pocketServer.AddItems(pockettest.Item{GivenURL: "https://blog.golang.org/", Favorite: "1"})
+	pocketServer.RejectAuthorization(true)
+
+	sess := request.NewSession(nil)
+	resp := followRedirect(t, sess, ts.URL, ts.URL, pocketServer.URL)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), "Authorization rejected")
+
+	// try again
+	pocketServer.RejectAuthorization(false)
+	resp = followRedirect(t, sess, ts.URL, ts.URL, pocketServer.URL)
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	require.Equal(t, "https://app.getpocket.com/read/"+ids[0], resp.Header.Get("Location"))
+}
+
+func TestLogout(t *testing.T) {
+	var service *pocketService
+	ts, pocketServer, teardown := newTestServer(func(s *pocketService) { service = s })
+	defer teardown()
+
+	pocketServer.AddItems(pockettest.Item{GivenURL: "https://blog.golang.org/", Favorite: "1"})
+
+	sess := request.NewSession(nil)
+	resp := followRedirect(t, sess, ts.URL, ts.URL, pocketServer.URL)
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	tokens := pocketServer.IssuedAccessTokens()
+	require.Equal(t, 1, len(tokens))
+	_, exists := service.cache.Get([]byte(favoritesCacheKey(tokens[0])))
+	require.True(t, exists)
+
+	resp, err := sess.Get(ts.URL + "/logout").Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	for _, key := range []string{favoritesCacheKey(tokens[0]), favoritesSyncKey(tokens[0])} {
+		_, exists := service.cache.Get([]byte(key))
+		require.False(t, exists, key)
+	}
+
+	// authorize again
+	resp, err = sess.Get(ts.URL).FollowRedirect(false).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	require.True(t, strings.HasPrefix(resp.Header.Get("Location"), pocketServer.AuthorizeURL()))
+}
+
+func TestIndexFilter(t *testing.T) {
+	ts, pocketServer, teardown := newTestServer()
+	defer teardown()
+
+	ids := pocketServer.AddItems(
+		pockettest.Item{GivenURL: "https://golang.org/", Favorite: "1", WordCount: "500",
+			Tags: map[string]pockettest.Tag{"go": {Tag: "go"}}},
+		pockettest.Item{GivenURL: "https://www.rust-lang.org/", Favorite: "1", WordCount: "5000",
+			Tags: map[string]pockettest.Tag{"rust": {Tag: "rust"}}},
+	)
+
+	sess := request.NewSession(nil)
+	resp := followRedirect(t, sess, ts.URL, ts.URL, pocketServer.URL)
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	pick := func(query string) *request.Response {
+		resp, err := sess.Get("%s/?%s", ts.URL, query).FollowRedirect(false).Do()
+		require.NoError(t, err)
+		return resp
+	}
+
+	type args struct {
+		query string
+	}
+	tests := [...]struct {
+		name       string
+		args       args
+		wantStatus int
+		wantItem   string
+	}{
+		{"tag", args{"tag=go"}, http.StatusFound, ids[0]},
+		{"domain", args{"domain=rust-lang.org"}, http.StatusFound, ids[1]},
+		{"max minutes", args{"max_minutes=5"}, http.StatusFound, ids[0]},
+		{"save preset", args{"tag=rust&preset=rust"}, http.StatusFound, ids[1]},
+		{"load preset", args{"preset=rust"}, http.StatusFound, ids[1]},
+		{"unknown preset", args{"preset=unknown"}, http.StatusNotFound, ""},
+		{"not matched", args{"tag=python"}, http.StatusNotFound, ""},
+		{"invalid", args{"state=deleted"}, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := pick(tt.args.query)
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+			if tt.wantItem != "" {
+				require.Equal(t, "https://app.getpocket.com/read/"+tt.wantItem, resp.Header.Get("Location"))
+			}
+		})
+	}
+}
+
+func TestIndexStrategy(t *testing.T) {
+	var service *pocketService
+	ts, pocketServer, teardown := newTestServer(func(s *pocketService) { service = s })
+	defer teardown()
+
+	ids := pocketServer.AddItems(pockettest.Item{GivenURL: "https://golang.org/", Favorite: "1"})
+
+	sess := request.NewSession(nil)
+	resp := followRedirect(t, sess, ts.URL, ts.URL, pocketServer.URL)
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	for _, strategy := range []string{PickUniform, PickOldest, PickRecent, PickTags, PickRarely} {
+		resp, err := sess.Get("%s/?strategy=%s", ts.URL, strategy).FollowRedirect(false).Do()
+		require.NoError(t, err)
+		require.Equal(t, http.StatusFound, resp.StatusCode, strategy)
+	}
+
+	resp, err := sess.Get("%s/?strategy=unknown", ts.URL).FollowRedirect(false).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// first pick while authorizing and 5 picks with strategies
+	tokens := pocketServer.IssuedAccessTokens()
+	require.Equal(t, map[string]int{ids[0]: 6}, service.pickCounts(tokens[0]))
+}
+
+func TestPickCountsLocked(t *testing.T) {
+	var service *pocketService
+	ts, pocketServer, teardown := newTestServer(func(s *pocketService) { service = s })
+	defer teardown()
+
+	ids := pocketServer.AddItems(
+		pockettest.Item{GivenURL: "https://golang.org/", Favorite: "1"},
+		pockettest.Item{GivenURL: "https://www.rust-lang.org/", Favorite: "1"},
+	)
+
+	// counts updated while the pick waits for the lock are used by the pick
+	unlock := service.pickLocks.lock(pockettest.AccessToken)
+	picked := make(chan Article)
+	go func() {
+		var article Article
+		resp, err := request.Get("%s/api/v1/pick", ts.URL).Param("strategy", PickRarely).AuthBearer(pockettest.AccessToken).Do()
+		if err == nil {
+			resp.JSON(&article)
+		}
+		picked <- article
+	}()
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, service.cache.Set([]byte(pickCountsKey(pockettest.AccessToken)), []byte(`{"`+ids[0]+`":1000000000}`)))
+	unlock()
+
+	require.Equal(t, ids[1], (<-picked).ItemID)
+}
+
+func TestHistory(t *testing.T) {
+	ts, pocketServer, teardown := newTestServer(func(s *pocketService) {
+		s.history = historyPolicy{mode: HistoryWindow, window: time.Hour, size: 10}
+	})
+	defer teardown()
+
+	resp, err := request.Get("%s/history", ts.URL).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	ids := pocketServer.AddItems(
+		pockettest.Item{GivenURL: "https://golang.org/", GivenTitle: "Go", Favorite: "1"},
+		pockettest.Item{GivenURL: "https://www.rust-lang.org/", GivenTitle: "Rust", Favorite: "1"},
+	)
+
+	sess := request.NewSession(nil)
+	first := followRedirect(t, sess, ts.URL, ts.URL, pocketServer.URL)
+	require.Equal(t, http.StatusFound, first.StatusCode)
+
+	// the other article is picked next
+	second, err := sess.Get(ts.URL).FollowRedirect(false).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusFound, second.StatusCode)
+	require.NotEqual(t, first.Header.Get("Location"), second.Header.Get("Location"))
+
+	resp, err = sess.Get("%s/history", ts.URL).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var entries []historyEntry
+	require.NoError(t, resp.JSON(&entries))
+	require.Equal(t, 2, len(entries))
+	require.Equal(t, "https://app.getpocket.com/read/"+entries[0].ItemID, second.Header.Get("Location"))
+	require.ElementsMatch(t, ids, []string{entries[0].ItemID, entries[1].ItemID})
+	for _, entry := range entries {
+		require.NotEqual(t, "", entry.Title)
+		require.NotEqual(t, "", entry.URL)
+	}
+}
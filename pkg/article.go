@@ -0,0 +1,308 @@
+package pocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ArticleStatus status of article
+type ArticleStatus int
+
+// values of ArticleStatus
+const (
+	ArticleUnread   ArticleStatus = 0
+	ArticleArchived ArticleStatus = 1
+	ArticleDeleted  ArticleStatus = 2 // only returned by delta request with since
+)
+
+// MediaStatus whether article has images or videos
+type MediaStatus int
+
+// values of MediaStatus
+const (
+	MediaNone MediaStatus = 0 // article has no images or videos
+	MediaHas  MediaStatus = 1 // article has images or videos in it
+	MediaIs   MediaStatus = 2 // article is an image or video
+)
+
+// Article pocket article, see https://getpocket.com/developer/docs/v3/retrieve
+//
+// Article is encoded to json in the same format as pocket does, numbers as string and times as unix timestamp.
+type Article struct {
+	ItemID         string
+	ResolvedID     string
+	GivenURL       string
+	GivenTitle     string
+	Favorite       bool
+	Status         ArticleStatus
+	SortID         int
+	ResolvedTitle  string
+	ResolvedURL    string
+	Excerpt        string
+	IsArticle      bool
+	IsIndex        bool
+	HasVideo       MediaStatus
+	HasImage       MediaStatus
+	WordCount      int
+	Lang           string
+	TimeToRead     int // estimated minutes to read
+	TopImageURL    string
+	TimeAdded      time.Time
+	TimeUpdated    time.Time
+	TimeRead       time.Time // zero if not read
+	TimeFavorited  time.Time // zero if not favorited
+	Tags           map[string]Tag
+	Authors        map[string]Author
+	Images         map[string]Image
+	Videos         map[string]Video
+	DomainMetadata *DomainMetadata
+}
+
+// Tag tag of article
+type Tag struct {
+	ItemID string `json:"item_id"`
+	Tag    string `json:"tag"`
+}
+
+// Author author of article
+type Author struct {
+	ItemID   string `json:"item_id"`
+	AuthorID string `json:"author_id"`
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+}
+
+// Image image in article
+type Image struct {
+	ItemID  string `json:"item_id"`
+	ImageID string `json:"image_id"`
+	Src     string `json:"src"`
+	Width   string `json:"width"`
+	Height  string `json:"height"`
+	Credit  string `json:"credit"`
+	Caption string `json:"caption"`
+}
+
+// Video video in article
+type Video struct {
+	ItemID  string `json:"item_id"`
+	VideoID string `json:"video_id"`
+	Src     string `json:"src"`
+	Width   string `json:"width"`
+	Height  string `json:"height"`
+	Type    string `json:"type"`
+	Vid     string `json:"vid"`
+}
+
+// DomainMetadata informations of article domain
+type DomainMetadata struct {
+	Name          string `json:"name"`
+	Logo          string `json:"logo"`
+	GreyscaleLogo string `json:"greyscale_logo"`
+}
+
+// TagNames return tag names sorted
+func (a *Article) TagNames() []string {
+	tags := make([]string, 0, len(a.Tags))
+	for tag := range a.Tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	return tags
+}
+
+// HasTag return true if article was tagged with tag
+func (a *Article) HasTag(tag string) bool {
+	_, ok := a.Tags[tag]
+	return ok
+}
+
+// Domain return host of article url without www.
+func (a *Article) Domain() string {
+	rawURL := a.ResolvedURL
+	if rawURL == "" {
+		rawURL = a.GivenURL
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// jsonInt integer which encoded as string in json like "123"; also accepts number, empty string and null
+type jsonInt int64
+
+func (i jsonInt) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(strconv.FormatInt(int64(i), 10))), nil
+}
+
+func (i *jsonInt) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		*i = 0
+		return nil
+	}
+
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	*i = jsonInt(v)
+
+	return nil
+}
+
+func fromUnix(t jsonInt) time.Time {
+	if t == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(t), 0)
+}
+
+func toUnix(t time.Time) jsonInt {
+	if t.IsZero() {
+		return 0
+	}
+	return jsonInt(t.Unix())
+}
+
+func fromBool(b bool) jsonInt {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// articleJSON wire format of Article
+type articleJSON struct {
+	ItemID         string          `json:"item_id"`
+	ResolvedID     string          `json:"resolved_id"`
+	GivenURL       string          `json:"given_url"`
+	GivenTitle     string          `json:"given_title"`
+	Favorite       jsonInt         `json:"favorite" swaggertype:"string"`
+	Status         jsonInt         `json:"status" swaggertype:"string"`
+	SortID         jsonInt         `json:"sort_id" swaggertype:"string"`
+	ResolvedTitle  string          `json:"resolved_title"`
+	ResolvedURL    string          `json:"resolved_url"`
+	Excerpt        string          `json:"excerpt"`
+	IsArticle      jsonInt         `json:"is_article" swaggertype:"string"`
+	IsIndex        jsonInt         `json:"is_index" swaggertype:"string"`
+	HasVideo       jsonInt         `json:"has_video" swaggertype:"string"`
+	HasImage       jsonInt         `json:"has_image" swaggertype:"string"`
+	WordCount      jsonInt         `json:"word_count" swaggertype:"string"`
+	Lang           string          `json:"lang"`
+	TimeToRead     jsonInt         `json:"time_to_read,omitempty" swaggertype:"string"`
+	TopImageURL    string          `json:"top_image_url,omitempty"`
+	TimeAdded      jsonInt         `json:"time_added" swaggertype:"string"`
+	TimeUpdated    jsonInt         `json:"time_updated" swaggertype:"string"`
+	TimeRead       jsonInt         `json:"time_read" swaggertype:"string"`
+	TimeFavorited  jsonInt         `json:"time_favorited" swaggertype:"string"`
+	Tags           tagMap          `json:"tags,omitempty"`
+	Authors        authorMap       `json:"authors,omitempty"`
+	Images         imageMap        `json:"images,omitempty"`
+	Videos         videoMap        `json:"videos,omitempty"`
+	DomainMetadata *DomainMetadata `json:"domain_metadata,omitempty"`
+}
+
+// maps of article which pocket encodes as [] if they are empty
+type (
+	tagMap    map[string]Tag
+	authorMap map[string]Author
+	imageMap  map[string]Image
+	videoMap  map[string]Video
+)
+
+func (m *tagMap) UnmarshalJSON(b []byte) error    { return unmarshalMap(b, (*map[string]Tag)(m)) }
+func (m *authorMap) UnmarshalJSON(b []byte) error { return unmarshalMap(b, (*map[string]Author)(m)) }
+func (m *imageMap) UnmarshalJSON(b []byte) error  { return unmarshalMap(b, (*map[string]Image)(m)) }
+func (m *videoMap) UnmarshalJSON(b []byte) error  { return unmarshalMap(b, (*map[string]Video)(m)) }
+
+// unmarshalMap decode json object to map; empty array is decoded as nil map
+func unmarshalMap(b []byte, v interface{}) error {
+	if string(bytes.Join(bytes.Fields(b), nil)) == "[]" {
+		return nil
+	}
+	return json.Unmarshal(b, v)
+}
+
+// MarshalJSON encode article in pocket format
+func (a Article) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&articleJSON{
+		ItemID:         a.ItemID,
+		ResolvedID:     a.ResolvedID,
+		GivenURL:       a.GivenURL,
+		GivenTitle:     a.GivenTitle,
+		Favorite:       fromBool(a.Favorite),
+		Status:         jsonInt(a.Status),
+		SortID:         jsonInt(a.SortID),
+		ResolvedTitle:  a.ResolvedTitle,
+		ResolvedURL:    a.ResolvedURL,
+		Excerpt:        a.Excerpt,
+		IsArticle:      fromBool(a.IsArticle),
+		IsIndex:        fromBool(a.IsIndex),
+		HasVideo:       jsonInt(a.HasVideo),
+		HasImage:       jsonInt(a.HasImage),
+		WordCount:      jsonInt(a.WordCount),
+		Lang:           a.Lang,
+		TimeToRead:     jsonInt(a.TimeToRead),
+		TopImageURL:    a.TopImageURL,
+		TimeAdded:      toUnix(a.TimeAdded),
+		TimeUpdated:    toUnix(a.TimeUpdated),
+		TimeRead:       toUnix(a.TimeRead),
+		TimeFavorited:  toUnix(a.TimeFavorited),
+		Tags:           a.Tags,
+		Authors:        a.Authors,
+		Images:         a.Images,
+		Videos:         a.Videos,
+		DomainMetadata: a.DomainMetadata,
+	})
+}
+
+// UnmarshalJSON decode article from pocket format
+func (a *Article) UnmarshalJSON(b []byte) error {
+	var v articleJSON
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	*a = Article{
+		ItemID:         v.ItemID,
+		ResolvedID:     v.ResolvedID,
+		GivenURL:       v.GivenURL,
+		GivenTitle:     v.GivenTitle,
+		Favorite:       v.Favorite != 0,
+		Status:         ArticleStatus(v.Status),
+		SortID:         int(v.SortID),
+		ResolvedTitle:  v.ResolvedTitle,
+		ResolvedURL:    v.ResolvedURL,
+		Excerpt:        v.Excerpt,
+		IsArticle:      v.IsArticle != 0,
+		IsIndex:        v.IsIndex != 0,
+		HasVideo:       MediaStatus(v.HasVideo),
+		HasImage:       MediaStatus(v.HasImage),
+		WordCount:      int(v.WordCount),
+		Lang:           v.Lang,
+		TimeToRead:     int(v.TimeToRead),
+		TopImageURL:    v.TopImageURL,
+		TimeAdded:      fromUnix(v.TimeAdded),
+		TimeUpdated:    fromUnix(v.TimeUpdated),
+		TimeRead:       fromUnix(v.TimeRead),
+		TimeFavorited:  fromUnix(v.TimeFavorited),
+		Tags:           v.Tags,
+		Authors:        v.Authors,
+		Images:         v.Images,
+		Videos:         v.Videos,
+		DomainMetadata: v.DomainMetadata,
+	}
+
+	return nil
+}
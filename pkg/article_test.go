@@ -0,0 +1,104 @@
+package pocket
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// sample from https://getpocket.com/developer/docs/v3/retrieve
+const sampleArticle = `{
+	"item_id": "229279689",
+	"resolved_id": "229279689",
+	"given_url": "http://www.grantland.com/blog/the-triangle/post/_/id/38347/ryder-cup-preview",
+	"given_title": "The Massive Ryder Cup Preview - The Triangle Blog - Grantland",
+	"favorite": "1",
+	"status": "1",
+	"time_added": "1346209293",
+	"time_updated": "1346209310",
+	"time_read": "1346209310",
+	"time_favorited": "0",
+	"sort_id": 0,
+	"resolved_title": "The Massive Ryder Cup Preview",
+	"resolved_url": "http://www.grantland.com/blog/the-triangle/post/_/id/38347/ryder-cup-preview",
+	"excerpt": "The list of things I love about the Ryder Cup is so long",
+	"is_article": "1",
+	"is_index": "0",
+	"has_video": "1",
+	"has_image": "2",
+	"word_count": "3197",
+	"lang": "en",
+	"time_to_read": 15,
+	"top_image_url": "https://example.com/top.jpg",
+	"tags": {"golf": {"item_id": "229279689", "tag": "golf"}, "sports": {"item_id": "229279689", "tag": "sports"}},
+	"authors": {"36": {"item_id": "229279689", "author_id": "36", "name": "Bill Barnwell", "url": ""}},
+	"images": {"1": {"item_id": "229279689", "image_id": "1", "src": "http://a.espncdn.com/photo/2012/0813/grant_g_ryder_cr_640.jpg", "width": "0", "height": "0", "credit": "Getty Images", "caption": ""}},
+	"domain_metadata": {"name": "Grantland", "logo": "https://logo.clearbit.com/grantland.com", "greyscale_logo": ""}
+}`
+
+func TestArticleUnmarshal(t *testing.T) {
+	var article Article
+	require.NoError(t, json.Unmarshal([]byte(sampleArticle), &article))
+
+	require.Equal(t, "229279689", article.ItemID)
+	require.Equal(t, "The Massive Ryder Cup Preview - The Triangle Blog - Grantland", article.GivenTitle)
+	require.True(t, article.Favorite)
+	require.Equal(t, ArticleArchived, article.Status)
+	require.True(t, article.IsArticle)
+	require.False(t, article.IsIndex)
+	require.Equal(t, MediaHas, article.HasVideo)
+	require.Equal(t, MediaIs, article.HasImage)
+	require.Equal(t, 3197, article.WordCount)
+	require.Equal(t, 15, article.TimeToRead)
+	require.Equal(t, "en", article.Lang)
+	require.Equal(t, time.Unix(1346209293, 0), article.TimeAdded)
+	require.Equal(t, time.Unix(1346209310, 0), article.TimeRead)
+	require.True(t, article.TimeFavorited.IsZero())
+	require.Equal(t, []string{"golf", "sports"}, article.TagNames())
+	require.True(t, article.HasTag("golf"))
+	require.Equal(t, "Bill Barnwell", article.Authors["36"].Name)
+	require.Equal(t, "Grantland", article.DomainMetadata.Name)
+	require.Equal(t, "Getty Images", article.Images["1"].Credit)
+}
+
+func TestArticleMarshal(t *testing.T) {
+	var article Article
+	require.NoError(t, json.Unmarshal([]byte(sampleArticle), &article))
+
+	buf, err := json.Marshal(article)
+	require.NoError(t, err)
+
+	// numbers are encoded as string like pocket does
+	var wire map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf, &wire))
+	require.Equal(t, "1", wire["favorite"])
+	require.Equal(t, "3197", wire["word_count"])
+	require.Equal(t, "0", wire["time_favorited"])
+
+	var decoded Article
+	require.NoError(t, json.Unmarshal(buf, &decoded))
+	require.Equal(t, article, decoded)
+}
+
+func TestArticleUnmarshalEmptyCollections(t *testing.T) {
+	var article Article
+	require.NoError(t, json.Unmarshal([]byte(`{"item_id": "1", "tags": [], "authors": [ ], "images": [], "videos": []}`), &article))
+	require.Equal(t, "1", article.ItemID)
+	require.Nil(t, article.Tags)
+	require.Nil(t, article.Authors)
+	require.Nil(t, article.Images)
+	require.Nil(t, article.Videos)
+
+	require.Error(t, json.Unmarshal([]byte(`{"item_id": "1", "tags": ["go"]}`), &article))
+}
+
+func TestArticleUnmarshalEmpty(t *testing.T) {
+	var article Article
+	require.NoError(t, json.Unmarshal([]byte(`{"item_id": "1", "status": "2"}`), &article))
+	require.Equal(t, ArticleDeleted, article.Status)
+	require.True(t, article.TimeAdded.IsZero())
+
+	require.Error(t, json.Unmarshal([]byte(`{"item_id": "1", "word_count": "many"}`), &article))
+}
@@ -0,0 +1,190 @@
+package pocket
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DefaultBatchSize max number of actions sent by a request
+const DefaultBatchSize = 100
+
+// Batch build actions of Modify API and send them in chunks
+//
+//	result, err := api.Articles.Batch().Archive("1", "2").TagsAdd("3", "go").Send(ctx)
+type Batch struct {
+	api     *ArticlesAPI
+	size    int
+	actions []articleActionParam
+}
+
+// ActionResult result of an action in batch
+type ActionResult struct {
+	Action  string
+	ItemID  string // empty for actions that not belong to an item such as tag_rename
+	Success bool
+	Err     error // error of request, nil if request was succeeded
+}
+
+// BatchResult results of actions in the order they were added
+type BatchResult struct {
+	Results []ActionResult
+}
+
+// Batch return new batch builder
+func (a *ArticlesAPI) Batch() *Batch {
+	return &Batch{
+		api:  a,
+		size: DefaultBatchSize,
+	}
+}
+
+// Size set max number of actions per request
+func (b *Batch) Size(size int) *Batch {
+	if size > 0 {
+		b.size = size
+	}
+	return b
+}
+
+// Len return number of actions in batch
+func (b *Batch) Len() int { return len(b.actions) }
+
+func (b *Batch) add(action string, itemIDs ...string) *Batch {
+	for _, itemID := range itemIDs {
+		b.actions = append(b.actions, articleActionParam{Action: action, ItemID: itemID})
+	}
+	return b
+}
+
+func (b *Batch) addTags(action string, itemID string, tags []string) *Batch {
+	b.actions = append(b.actions, articleActionParam{Action: action, ItemID: itemID, Tags: strings.Join(tags, ",")})
+	return b
+}
+
+// Delete add delete actions
+func (b *Batch) Delete(itemIDs ...string) *Batch { return b.add(ActionDelete, itemIDs...) }
+
+// Archive add archive actions
+func (b *Batch) Archive(itemIDs ...string) *Batch { return b.add(ActionArchive, itemIDs...) }
+
+// Readd add readd actions
+func (b *Batch) Readd(itemIDs ...string) *Batch { return b.add(ActionReadd, itemIDs...) }
+
+// Favorite add favorite actions
+func (b *Batch) Favorite(itemIDs ...string) *Batch { return b.add(ActionFavorite, itemIDs...) }
+
+// Unfavorite add unfavorite actions
+func (b *Batch) Unfavorite(itemIDs ...string) *Batch { return b.add(ActionUnfavorite, itemIDs...) }
+
+// TagsClear add tags_clear actions
+func (b *Batch) TagsClear(itemIDs ...string) *Batch { return b.add(ActionTagsClear, itemIDs...) }
+
+// TagsAdd add tags_add action
+func (b *Batch) TagsAdd(itemID string, tags ...string) *Batch {
+	return b.addTags(ActionTagsAdd, itemID, tags)
+}
+
+// TagsRemove add tags_remove action
+func (b *Batch) TagsRemove(itemID string, tags ...string) *Batch {
+	return b.addTags(ActionTagsRemove, itemID, tags)
+}
+
+// TagsReplace add tags_replace action
+func (b *Batch) TagsReplace(itemID string, tags ...string) *Batch {
+	return b.addTags(ActionTagsReplace, itemID, tags)
+}
+
+// TagRename add tag_rename action
+func (b *Batch) TagRename(oldTag, newTag string) *Batch {
+	b.actions = append(b.actions, articleActionParam{Action: ActionTagRename, OldTag: oldTag, NewTag: newTag})
+	return b
+}
+
+// TagDelete add tag_delete action
+func (b *Batch) TagDelete(tag string) *Batch {
+	b.actions = append(b.actions, articleActionParam{Action: ActionTagDelete, Tag: tag})
+	return b
+}
+
+// Add add add action
+func (b *Batch) Add(url, title string, tags []string, tweetID string) *Batch {
+	b.actions = append(b.actions, articleActionParam{
+		Action: ActionAdd,
+		URL:    url,
+		Title:  title,
+		Tags:   strings.Join(tags, ","),
+		RefID:  tweetID,
+	})
+	return b
+}
+
+// Send send actions in chunks of batch size
+// all chunks are sent even if some of them failed; error is returned if any of actions failed
+func (b *Batch) Send(ctx context.Context) (*BatchResult, error) {
+	result := &BatchResult{Results: make([]ActionResult, 0, len(b.actions))}
+
+	for start := 0; start < len(b.actions); start += b.size {
+		end := start + b.size
+		if end > len(b.actions) {
+			end = len(b.actions)
+		}
+		chunk := b.actions[start:end]
+
+		response, err := b.api.send(ctx, chunk)
+		for i, action := range chunk {
+			r := ActionResult{
+				Action: action.Action,
+				ItemID: action.ItemID,
+				Err:    err,
+			}
+			if err == nil {
+				r.Success = response.succeeded(i)
+			}
+			result.Results = append(result.Results, r)
+		}
+	}
+
+	return result, result.Err()
+}
+
+// Succeeded return item ids of succeeded actions
+func (r *BatchResult) Succeeded() []string { return r.itemIDs(true) }
+
+// Failed return item ids of failed actions
+func (r *BatchResult) Failed() []string { return r.itemIDs(false) }
+
+func (r *BatchResult) itemIDs(success bool) []string {
+	var itemIDs []string
+	for _, result := range r.Results {
+		if result.Success == success && result.ItemID != "" {
+			itemIDs = append(itemIDs, result.ItemID)
+		}
+	}
+
+	return itemIDs
+}
+
+// Err return error if any of actions failed
+func (r *BatchResult) Err() error {
+	failed := 0
+	var err error
+	for _, result := range r.Results {
+		if !result.Success {
+			failed++
+			if err == nil {
+				err = result.Err
+			}
+		}
+	}
+
+	if failed == 0 {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("%d of %d actions failed, failed items=%s: %w", failed, len(r.Results), r.Failed(), err)
+	}
+
+	return fmt.Errorf("%d of %d actions failed, failed items=%s: %w", failed, len(r.Results), r.Failed(), ErrActionFailed)
+}
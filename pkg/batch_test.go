@@ -0,0 +1,62 @@
+package pocket
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/whitekid/pocket-pick/pkg/pockettest"
+)
+
+func TestBatch(t *testing.T) {
+	pocketServer := pockettest.NewServer()
+	defer pocketServer.Close()
+
+	var ids []string
+	for i := 0; i < 25; i++ {
+		ids = append(ids, pocketServer.AddItems(pockettest.Item{GivenURL: "https://golang.org/" + strconv.Itoa(i)})...)
+	}
+
+	api := newTestAPI(pocketServer, pockettest.AccessToken)
+	batch := api.Articles.Batch().Size(10).
+		Archive(ids[:20]...).
+		TagsAdd(ids[20], "go").
+		Favorite("not-found").
+		Delete(ids[21:]...)
+	require.Equal(t, 26, batch.Len())
+
+	result, err := batch.Send(context.Background())
+	require.Error(t, err)
+	require.Equal(t, 3, pocketServer.RequestCount("/v3/send"))
+	require.Equal(t, 26, len(result.Results))
+	require.Equal(t, []string{"not-found"}, result.Failed())
+	require.Equal(t, 25, len(result.Succeeded()))
+
+	require.Equal(t, 21, len(pocketServer.Items()))
+	item, _ := pocketServer.Item(ids[0])
+	require.Equal(t, "1", item.Status)
+	item, _ = pocketServer.Item(ids[20])
+	require.Equal(t, 1, len(item.Tags))
+}
+
+func TestBatchRequestFailed(t *testing.T) {
+	pocketServer := pockettest.NewServer()
+	defer pocketServer.Close()
+	ids := pocketServer.AddItems(
+		pockettest.Item{GivenURL: "https://golang.org/"},
+		pockettest.Item{GivenURL: "https://go.dev/"},
+	)
+
+	// first chunk fails
+	pocketServer.InjectFault(pockettest.Fault{Path: "/v3/send", StatusCode: http.StatusBadRequest, Times: 1})
+
+	api := newTestAPI(pocketServer, pockettest.AccessToken)
+	result, err := api.Articles.Batch().Size(1).Archive(ids...).Send(context.Background())
+	require.Error(t, err)
+	require.Equal(t, []string{ids[0]}, result.Failed())
+	require.Equal(t, []string{ids[1]}, result.Succeeded())
+	require.Error(t, result.Results[0].Err)
+	require.NoError(t, result.Results[1].Err)
+}
@@ -1,29 +1,86 @@
 package pocket
 
 import (
+	"encoding/binary"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/allegro/bigcache/v3"
+	"github.com/whitekid/pocket-pick/pkg/config"
 )
 
+// cache backends
+const (
+	CacheMemory = "memory" // in-process bigcache, lost on restart
+	CacheBolt   = "bolt"   // bolt database file on disk
+	CacheRedis  = "redis"  // redis server
+)
+
+// cacher key value cache; entries expire by ttl given by withTTL or never expire
 type cacher interface {
 	Set(key, value []byte, opts ...setOption) error
-	Get(key []byte) ([]byte, bool)
-	Has(key []byte) bool
+	Get(key []byte) ([]byte, bool) // false if not exists or expired
+	Has(key []byte) bool           // true if exists and not expired
+	Delete(key []byte) error       // no error if not exists
+	Stats() cacheStats
+	Close() error // flush and release the cache
+}
+
+// cacheStats statistics of cache
+type cacheStats struct {
+	Backend   string          `json:"backend"`
+	Hits      int64           `json:"hits"`
+	Misses    int64           `json:"misses"`    // not exists or expired
+	Evictions int64           `json:"evictions"` // entries removed by cache because of expiration or no space
+	Entries   int64           `json:"entries"`
+	Bytes     int64           `json:"bytes"` // size of cache, 0 if backend does not report it
+	BigCache  *bigcache.Stats `json:"bigcache,omitempty"`
+}
+
+// cacheCounters counters of cache backends
+type cacheCounters struct {
+	hits      int64
+	misses    int64
+	evictions int64
 }
 
-func newBigCache() cacher {
-	config := bigcache.DefaultConfig(time.Hour)
-	config.CleanWindow = time.Minute
-	cache, _ := bigcache.NewBigCache(config)
-	return &bigCacher{
-		cache: cache,
+func (c *cacheCounters) lookup(hit bool) {
+	if hit {
+		atomic.AddInt64(&c.hits, 1)
+	} else {
+		atomic.AddInt64(&c.misses, 1)
 	}
 }
 
-type bigCacher struct {
-	cache *bigcache.BigCache
+func (c *cacheCounters) evicted(n int) { atomic.AddInt64(&c.evictions, int64(n)) }
+
+func (c *cacheCounters) stats(backend string) cacheStats {
+	return cacheStats{
+		Backend:   backend,
+		Hits:      atomic.LoadInt64(&c.hits),
+		Misses:    atomic.LoadInt64(&c.misses),
+		Evictions: atomic.LoadInt64(&c.evictions),
+	}
+}
+
+// newCacheFromConfig create cache with config
+func newCacheFromConfig() (cacher, error) {
+	return newCache(config.CacheBackend(), config.CacheSize(), config.CachePath(), config.CacheRedisURL())
+}
+
+// newCache create cache of backend; size in MB is for memory backend
+func newCache(backend string, size int, path string, redisURL string) (cacher, error) {
+	switch backend {
+	case CacheMemory, "":
+		return newBigCache(size)
+	case CacheBolt:
+		return newBoltCache(path)
+	case CacheRedis:
+		return newRedisCache(redisURL)
+	}
+
+	return nil, fmt.Errorf("unknown cache backend: %s", backend)
 }
 
 type setOptions struct {
@@ -51,50 +108,34 @@ func withTTL(ttl time.Duration) setOption {
 	})
 }
 
-func (b *bigCacher) Set(key, value []byte, opts ...setOption) error {
-	var sOpts setOptions
-	for _, o := range opts {
-		o.apply(&sOpts)
-	}
-
-	if err := b.cache.Set(string(key), value); err != nil {
-		return err
+// encodeEntry encode value with its expire time; zero expire never expires
+func encodeEntry(value []byte, expire time.Time) []byte {
+	var expireAt int64
+	if !expire.IsZero() {
+		expireAt = expire.UnixNano()
 	}
 
-	if sOpts.ttl != nil {
-		timez := sOpts.ttl.Format(time.RFC3339)
-		b.cache.Set(fmt.Sprintf("%s/expire", key), []byte(timez))
-	}
-
-	return nil
+	buf := make([]byte, 8+len(value))
+	binary.BigEndian.PutUint64(buf, uint64(expireAt))
+	copy(buf[8:], value)
+	return buf
 }
 
-func (b *bigCacher) Get(key []byte) ([]byte, bool) {
-	data, err := b.cache.Get(string(key))
-	if err != nil {
-		if err == bigcache.ErrEntryNotFound {
-			return nil, false
-		}
-		return nil, false
+// decodeEntry decode value and expire time encoded by encodeEntry
+func decodeEntry(data []byte) ([]byte, time.Time, error) {
+	if len(data) < 8 {
+		return nil, time.Time{}, fmt.Errorf("invalid cache entry: %d bytes", len(data))
 	}
 
-	expireb, err := b.cache.Get(fmt.Sprintf("%s/expire", key))
-	if err != nil {
-		return data, true
+	var expire time.Time
+	if expireAt := int64(binary.BigEndian.Uint64(data)); expireAt != 0 {
+		expire = time.Unix(0, expireAt)
 	}
 
-	expire, err := time.Parse(time.RFC3339, string(expireb))
-	if err != nil {
-		return data, true
-	}
-
-	if expire.Before(time.Now()) {
-		return nil, false
-	}
-	return data, true
+	return data[8:], expire, nil
 }
 
-func (b *bigCacher) Has(key []byte) bool {
-	_, err := b.cache.Get(string(key))
-	return err != nil
+// entryExpired return true if entry which expires at expire is expired at now
+func entryExpired(expire, now time.Time) bool {
+	return !expire.IsZero() && !now.Before(expire)
 }
@@ -0,0 +1,223 @@
+package pocket
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/pkg/errors"
+	"github.com/whitekid/go-utils/log"
+)
+
+const (
+	// bigCacheLifeWindow bigcache evicts entries older than its global life window regardless of ttl of entries,
+	// and reclaims space of overwritten or deleted entries only by the life window.
+	// it is longer than default favorites ttl, and entries living longer are written again before the window
+	bigCacheLifeWindow = 48 * time.Hour
+
+	// bigCacheCleanWindow interval to remove expired entries and rewrite long-lived entries
+	bigCacheCleanWindow = time.Minute
+
+	// bigCacheShards number of shards; an entry should fit in a shard, size / shards
+	bigCacheShards = 16
+)
+
+// bigCacher in-process cache on bigcache; entries are stored with their expire time
+// when the cache is full, bigcache drops the oldest entries even if they are not expired,
+// so the size should be large enough for the users; evictions in stats count them
+type bigCacher struct {
+	cacheCounters
+
+	cache *bigcache.BigCache
+	now   func() time.Time
+	mu    sync.Mutex // writes are serialized not to renew entries with old values
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newBigCache create memory cache up to size in MB
+// default config of bigcache reserves hundreds of MB up front, so memory is allocated as entries grow
+func newBigCache(size int) (cacher, error) {
+	b := &bigCacher{
+		now:  time.Now,
+		done: make(chan struct{}),
+	}
+
+	config := bigcache.DefaultConfig(bigCacheLifeWindow)
+	config.CleanWindow = bigCacheCleanWindow
+	config.Shards = bigCacheShards
+	config.MaxEntriesInWindow = 1024
+	config.MaxEntrySize = 1024
+	config.HardMaxCacheSize = size
+	config.OnRemoveWithReason = func(key string, entry []byte, reason bigcache.RemoveReason) {
+		if reason != bigcache.Deleted {
+			b.evicted(1)
+		}
+	}
+	cache, err := bigcache.NewBigCache(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "create bigcache")
+	}
+	b.cache = cache
+
+	go b.cleaner(bigCacheCleanWindow)
+
+	return b, nil
+}
+
+func (b *bigCacher) Set(key, value []byte, opts ...setOption) error {
+	var sOpts setOptions
+	for _, o := range opts {
+		o.apply(&sOpts)
+	}
+
+	var expire time.Time
+	if sOpts.ttl != nil {
+		expire = *sOpts.ttl
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.cache.Set(string(key), encodeEntry(value, expire))
+}
+
+func (b *bigCacher) Get(key []byte) ([]byte, bool) {
+	value, ok := b.get(key)
+	b.lookup(ok)
+	return value, ok
+}
+
+func (b *bigCacher) get(key []byte) ([]byte, bool) {
+	data, err := b.cache.Get(string(key))
+	if err != nil {
+		if err != bigcache.ErrEntryNotFound {
+			log.Errorf("get cache %s failed: %s", key, err)
+		}
+		return nil, false
+	}
+
+	value, expire, err := decodeEntry(data)
+	if err != nil {
+		log.Errorf("get cache %s failed: %s", key, err)
+		return nil, false
+	}
+
+	if now := b.now(); entryExpired(expire, now) {
+		b.deleteExpired(string(key), now)
+		return nil, false
+	}
+
+	return value, true
+}
+
+func (b *bigCacher) Has(key []byte) bool {
+	_, exists := b.get(key)
+	return exists
+}
+
+func (b *bigCacher) Delete(key []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.cache.Delete(string(key)); err != nil && err != bigcache.ErrEntryNotFound {
+		return err
+	}
+	return nil
+}
+
+func (b *bigCacher) Stats() cacheStats {
+	stats := b.stats(CacheMemory)
+	stats.Entries = int64(b.cache.Len())
+	stats.Bytes = int64(b.cache.Capacity())
+
+	bigStats := b.cache.Stats()
+	stats.BigCache = &bigStats
+	return stats
+}
+
+func (b *bigCacher) Close() error {
+	var err error
+	b.closeOnce.Do(func() {
+		close(b.done)
+		err = b.cache.Close()
+	})
+	return err
+}
+
+// cleaner remove expired entries periodically until cache is closed
+func (b *bigCacher) cleaner(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.sweep(b.now())
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// sweep remove entries expired before now, and write again entries that are older than half of life window
+func (b *bigCacher) sweep(now time.Time) {
+	var expired []string
+	renew := make(map[string][]byte)
+
+	it := b.cache.Iterator()
+	for it.SetNext() {
+		entry, err := it.Value()
+		if err != nil {
+			continue
+		}
+
+		if _, expire, err := decodeEntry(entry.Value()); err != nil || entryExpired(expire, now) {
+			expired = append(expired, entry.Key())
+		} else if now.Sub(time.Unix(int64(entry.Timestamp()), 0)) > bigCacheLifeWindow/2 {
+			renew[entry.Key()] = entry.Value()
+		}
+	}
+
+	for _, key := range expired {
+		b.deleteExpired(key, now)
+	}
+
+	for key, value := range renew {
+		b.renew(key, value)
+	}
+}
+
+// deleteExpired delete the entry if it is still expired; it could be written again after checked
+func (b *bigCacher) deleteExpired(key string, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	data, err := b.cache.Get(key)
+	if err != nil {
+		return
+	}
+
+	if _, expire, err := decodeEntry(data); err == nil && !entryExpired(expire, now) {
+		return
+	}
+
+	if b.cache.Delete(key) == nil {
+		b.evicted(1)
+	}
+}
+
+// renew write the entry again unless it was changed
+func (b *bigCacher) renew(key string, value []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if current, err := b.cache.Get(key); err != nil || !bytes.Equal(current, value) {
+		return
+	}
+
+	if err := b.cache.Set(key, value); err != nil {
+		log.Errorf("renew cache %s failed: %s", key, err)
+	}
+}
@@ -0,0 +1,183 @@
+package pocket
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/whitekid/go-utils/log"
+	bolt "go.etcd.io/bbolt"
+)
+
+var boltBucket = []byte("cache")
+
+// boltCleanWindow interval to remove expired entries
+var boltCleanWindow = time.Minute
+
+// boltCacher cache on bolt database file; entries survive restarts
+type boltCacher struct {
+	cacheCounters
+
+	db *bolt.DB
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+func newBoltCache(path string) (cacher, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, errors.Wrapf(err, "open cache %s", path)
+	}
+
+	b := &boltCacher{db: db, done: make(chan struct{})}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "create cache bucket")
+	}
+
+	if err := b.sweep(time.Now()); err != nil {
+		log.Errorf("sweep expired cache failed: %s", err)
+	}
+
+	b.wg.Add(1)
+	go b.cleaner(boltCleanWindow)
+
+	return b, nil
+}
+
+func (b *boltCacher) Set(key, value []byte, opts ...setOption) error {
+	var sOpts setOptions
+	for _, o := range opts {
+		o.apply(&sOpts)
+	}
+
+	var expire time.Time
+	if sOpts.ttl != nil {
+		expire = *sOpts.ttl
+	}
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put(key, encodeEntry(value, expire))
+	})
+}
+
+func (b *boltCacher) Get(key []byte) ([]byte, bool) {
+	value, ok := b.get(key)
+	b.lookup(ok)
+	return value, ok
+}
+
+func (b *boltCacher) get(key []byte) ([]byte, bool) {
+	var value []byte
+	if err := b.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(boltBucket).Get(key)
+		if data == nil {
+			return nil
+		}
+
+		v, expire, err := decodeEntry(data)
+		if err != nil {
+			return err
+		}
+
+		if entryExpired(expire, time.Now()) {
+			return nil
+		}
+
+		// data is valid only in the transaction
+		value = append([]byte{}, v...)
+		return nil
+	}); err != nil {
+		log.Errorf("get cache %s failed: %s", key, err)
+		return nil, false
+	}
+
+	return value, value != nil
+}
+
+func (b *boltCacher) Has(key []byte) bool {
+	_, exists := b.get(key)
+	return exists
+}
+
+func (b *boltCacher) Delete(key []byte) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete(key)
+	})
+}
+
+func (b *boltCacher) Stats() cacheStats {
+	stats := b.stats(CacheBolt)
+	if err := b.db.View(func(tx *bolt.Tx) error {
+		stats.Entries = int64(tx.Bucket(boltBucket).Stats().KeyN)
+		stats.Bytes = tx.Size()
+		return nil
+	}); err != nil {
+		log.Errorf("cache stats failed: %s", err)
+	}
+
+	return stats
+}
+
+func (b *boltCacher) Close() error {
+	var err error
+	b.closeOnce.Do(func() {
+		close(b.done)
+		b.wg.Wait()
+		err = b.db.Close()
+	})
+	return err
+}
+
+// cleaner remove expired entries periodically until cache is closed
+func (b *boltCacher) cleaner(interval time.Duration) {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := b.sweep(time.Now()); err != nil {
+				log.Errorf("sweep expired cache failed: %s", err)
+			}
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// sweep remove entries expired before now
+func (b *boltCacher) sweep(now time.Time) error {
+	var swept int
+	defer func() { b.evicted(swept) }()
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+
+		// deleting while iterating skips entries, collect keys first
+		var expired [][]byte
+		if err := bucket.ForEach(func(k, v []byte) error {
+			if _, expire, err := decodeEntry(v); err != nil || entryExpired(expire, now) {
+				expired = append(expired, append([]byte{}, k...))
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		swept = len(expired)
+		return nil
+	})
+}
@@ -0,0 +1,115 @@
+package pocket
+
+import (
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/pkg/errors"
+	"github.com/whitekid/go-utils/log"
+)
+
+// redisCacher cache on redis server; redis expires entries by itself
+type redisCacher struct {
+	cacheCounters
+
+	pool *redis.Pool
+}
+
+func newRedisCache(url string) (cacher, error) {
+	pool := &redis.Pool{
+		MaxIdle:     3,
+		IdleTimeout: 4 * time.Minute,
+		Dial:        func() (redis.Conn, error) { return redis.DialURL(url) },
+	}
+
+	conn := pool.Get()
+	defer conn.Close()
+
+	if _, err := conn.Do("PING"); err != nil {
+		pool.Close()
+		return nil, errors.Wrapf(err, "connect redis %s", url)
+	}
+
+	return &redisCacher{pool: pool}, nil
+}
+
+func (r *redisCacher) Set(key, value []byte, opts ...setOption) error {
+	var sOpts setOptions
+	for _, o := range opts {
+		o.apply(&sOpts)
+	}
+
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	args := redis.Args{}.Add(key, value)
+	if sOpts.ttl != nil {
+		ttl := time.Until(*sOpts.ttl).Milliseconds()
+		if ttl <= 0 {
+			_, err := conn.Do("DEL", key)
+			return err
+		}
+		args = args.Add("PX", ttl)
+	}
+
+	_, err := conn.Do("SET", args...)
+	return err
+}
+
+func (r *redisCacher) Get(key []byte) ([]byte, bool) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	value, err := redis.Bytes(conn.Do("GET", key))
+	if err != nil {
+		if err != redis.ErrNil {
+			log.Errorf("get cache %s failed: %s", key, err)
+		}
+		r.lookup(false)
+		return nil, false
+	}
+
+	r.lookup(true)
+	return value, true
+}
+
+func (r *redisCacher) Has(key []byte) bool {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	exists, err := redis.Bool(conn.Do("EXISTS", key))
+	if err != nil {
+		log.Errorf("check cache %s failed: %s", key, err)
+		return false
+	}
+
+	return exists
+}
+
+func (r *redisCacher) Delete(key []byte) error {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("DEL", key)
+	return err
+}
+
+// Stats return stats of the cache; redis expires entries by itself, so evictions are not counted
+func (r *redisCacher) Stats() cacheStats {
+	stats := r.stats(CacheRedis)
+
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	entries, err := redis.Int64(conn.Do("DBSIZE"))
+	if err != nil {
+		log.Errorf("cache stats failed: %s", err)
+	}
+	stats.Entries = entries
+
+	return stats
+}
+
+func (r *redisCacher) Close() error {
+	return r.pool.Close()
+}
@@ -0,0 +1,248 @@
+package pocket
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCache create cache of backend; redis backend runs on in-process redis
+func newTestCache(t *testing.T, backend string) (cacher, func()) {
+	mr := miniredis.NewMiniRedis()
+	require.NoError(t, mr.Start())
+
+	cache, err := newCache(backend, 16, filepath.Join(t.TempDir(), "cache.db"), "redis://"+mr.Addr())
+	require.NoError(t, err)
+
+	return cache, func() {
+		cache.Close()
+		mr.Close()
+	}
+}
+
+func TestCacheBackends(t *testing.T) {
+	type args struct {
+		opts []setOption
+	}
+	tests := [...]struct {
+		name   string
+		args   args
+		wantOK bool
+	}{
+		{"no ttl", args{nil}, true},
+		{"ttl", args{[]setOption{withTTL(time.Hour)}}, true},
+		{"expired", args{[]setOption{withTTL(-time.Second)}}, false},
+	}
+	for _, backend := range []string{CacheMemory, CacheBolt, CacheRedis} {
+		for _, tt := range tests {
+			t.Run(backend+"/"+tt.name, func(t *testing.T) {
+				cache, teardown := newTestCache(t, backend)
+				defer teardown()
+
+				key := []byte("hello")
+				require.NoError(t, cache.Set(key, []byte("world"), tt.args.opts...))
+
+				value, ok := cache.Get(key)
+				require.Equal(t, tt.wantOK, ok)
+				require.Equal(t, tt.wantOK, cache.Has(key))
+				if tt.wantOK {
+					require.Equal(t, []byte("world"), value)
+				}
+
+				require.NoError(t, cache.Delete(key))
+				_, ok = cache.Get(key)
+				require.False(t, ok)
+				require.False(t, cache.Has(key))
+
+				// delete of unknown key is not an error
+				require.NoError(t, cache.Delete([]byte("unknown")))
+			})
+		}
+	}
+}
+
+func TestCacheStats(t *testing.T) {
+	for _, backend := range []string{CacheMemory, CacheBolt, CacheRedis} {
+		t.Run(backend, func(t *testing.T) {
+			cache, teardown := newTestCache(t, backend)
+			defer teardown()
+
+			require.NoError(t, cache.Set([]byte("hello"), []byte("world")))
+			require.NoError(t, cache.Set([]byte("short"), []byte("lived"), withTTL(time.Hour)))
+
+			_, ok := cache.Get([]byte("hello"))
+			require.True(t, ok)
+			_, ok = cache.Get([]byte("unknown"))
+			require.False(t, ok)
+			require.True(t, cache.Has([]byte("hello")), "has is not counted")
+
+			stats := cache.Stats()
+			require.Equal(t, backend, stats.Backend)
+			require.Equal(t, int64(1), stats.Hits)
+			require.Equal(t, int64(1), stats.Misses)
+			require.Equal(t, int64(2), stats.Entries)
+			if backend != CacheRedis {
+				require.NotZero(t, stats.Bytes)
+			}
+			require.Equal(t, backend == CacheMemory, stats.BigCache != nil)
+		})
+	}
+}
+
+func TestBigCacheTTL(t *testing.T) {
+	c, err := newBigCache(16)
+	require.NoError(t, err)
+	cache := c.(*bigCacher)
+	defer cache.Close()
+
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	// ttl longer than default life window of bigcache
+	require.NoError(t, cache.Set([]byte("long"), []byte("value"), withTTL(3*time.Hour)))
+	require.NoError(t, cache.Set([]byte("short"), []byte("value"), withTTL(time.Hour)))
+	require.NoError(t, cache.Set([]byte("forever"), []byte("value")))
+
+	now = now.Add(2 * time.Hour)
+	require.True(t, cache.Has([]byte("long")))
+	require.False(t, cache.Has([]byte("short")))
+	require.True(t, cache.Has([]byte("forever")))
+
+	// sweep remove expired entries from bigcache
+	now = now.Add(2 * time.Hour)
+	cache.sweep(now)
+	require.Equal(t, 1, cache.cache.Len())
+	require.True(t, cache.Has([]byte("forever")))
+
+	// overwrite replaces ttl together with value
+	require.NoError(t, cache.Set([]byte("forever"), []byte("changed"), withTTL(-time.Second)))
+	require.False(t, cache.Has([]byte("forever")))
+	require.Equal(t, 0, cache.cache.Len())
+	require.Equal(t, int64(3), cache.Stats().Evictions)
+
+	require.NoError(t, cache.Close())
+	require.NoError(t, cache.Close(), "close twice")
+}
+
+func TestBigCacheRenew(t *testing.T) {
+	c, err := newBigCache(16)
+	require.NoError(t, err)
+	cache := c.(*bigCacher)
+	defer cache.Close()
+
+	require.NoError(t, cache.Set([]byte("forever"), []byte("value")))
+	require.NoError(t, cache.Set([]byte("long"), []byte("value"), withTTL(3*bigCacheLifeWindow)))
+
+	// entries older than half of life window are written again
+	cache.sweep(time.Now().Add(bigCacheLifeWindow))
+	for _, key := range []string{"forever", "long"} {
+		value, ok := cache.Get([]byte(key))
+		require.True(t, ok, key)
+		require.Equal(t, []byte("value"), value)
+	}
+
+	// renew does not overwrite changed entry
+	old, err := cache.cache.Get("forever")
+	require.NoError(t, err)
+	require.NoError(t, cache.Set([]byte("forever"), []byte("changed")))
+	cache.renew("forever", old)
+	value, _ := cache.Get([]byte("forever"))
+	require.Equal(t, []byte("changed"), value)
+}
+
+func TestEncodeEntry(t *testing.T) {
+	type args struct {
+		value  []byte
+		expire time.Time
+	}
+	tests := [...]struct {
+		name string
+		args args
+	}{
+		{"never expires", args{[]byte("value"), time.Time{}}},
+		{"expires", args{[]byte("value"), time.Unix(0, 1614556800123456789)}},
+		{"empty", args{[]byte{}, time.Unix(1614556800, 0)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, expire, err := decodeEntry(encodeEntry(tt.args.value, tt.args.expire))
+			require.NoError(t, err)
+			require.Equal(t, tt.args.value, value)
+			require.True(t, tt.args.expire.Equal(expire), "expire=%s, want %s", expire, tt.args.expire)
+		})
+	}
+
+	_, _, err := decodeEntry([]byte("short"))
+	require.Error(t, err)
+}
+
+func TestCacheUnknownBackend(t *testing.T) {
+	_, err := newCache("unknown", 0, "", "")
+	require.Error(t, err)
+}
+
+func TestBoltCachePersistent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	cache, err := newBoltCache(path)
+	require.NoError(t, err)
+	require.NoError(t, cache.Set([]byte("persistent"), []byte("value")))
+	require.NoError(t, cache.Set([]byte("temporary"), []byte("value"), withTTL(time.Hour)))
+	require.NoError(t, cache.Set([]byte("expired"), []byte("value"), withTTL(-time.Second)))
+	require.True(t, cache.Has([]byte("persistent")))
+	require.False(t, cache.Has([]byte("expired")))
+	require.NoError(t, cache.Close())
+
+	cache, err = newBoltCache(path)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	value, ok := cache.Get([]byte("persistent"))
+	require.True(t, ok)
+	require.Equal(t, []byte("value"), value)
+	require.True(t, cache.Has([]byte("temporary")))
+	require.False(t, cache.Has([]byte("expired")))
+}
+
+func TestBoltCacheCleaner(t *testing.T) {
+	defer func(window time.Duration) { boltCleanWindow = window }(boltCleanWindow)
+	boltCleanWindow = 10 * time.Millisecond
+
+	cache, err := newBoltCache(filepath.Join(t.TempDir(), "cache.db"))
+	require.NoError(t, err)
+
+	require.NoError(t, cache.Set([]byte("persistent"), []byte("value")))
+	require.NoError(t, cache.Set([]byte("expiring"), []byte("value"), withTTL(50*time.Millisecond)))
+	require.Equal(t, int64(2), cache.Stats().Entries)
+
+	require.Eventually(t, func() bool { return cache.Stats().Entries == 1 }, time.Second, 10*time.Millisecond)
+	require.Equal(t, int64(1), cache.Stats().Evictions)
+	require.True(t, cache.Has([]byte("persistent")))
+
+	require.NoError(t, cache.Close())
+	require.NoError(t, cache.Close(), "close twice")
+}
+
+func TestRedisCacheTTL(t *testing.T) {
+	mr := miniredis.NewMiniRedis()
+	require.NoError(t, mr.Start())
+	defer mr.Close()
+
+	cache, err := newRedisCache("redis://" + mr.Addr())
+	require.NoError(t, err)
+	defer cache.Close()
+
+	require.NoError(t, cache.Set([]byte("hello"), []byte("world"), withTTL(time.Minute)))
+	require.True(t, cache.Has([]byte("hello")))
+
+	mr.FastForward(2 * time.Minute)
+	require.False(t, cache.Has([]byte("hello")))
+	_, ok := cache.Get([]byte("hello"))
+	require.False(t, ok)
+
+	_, err = newRedisCache("redis://127.0.0.1:1")
+	require.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"strings"
 	"time"
 
 	"github.com/spf13/pflag"
@@ -9,20 +10,58 @@ import (
 )
 
 const (
-	keyBind         = "bind_addr"
-	keyRootURL      = "root_url"
-	keyConsumerKey  = "consumer_key"
-	keyAccessToken  = "access_token"
-	keyCacheTimeout = "favorite_cache_timeout"
+	keyBind              = "bind_addr"
+	keyRootURL           = "root_url"
+	keyConsumerKey       = "consumer_key"
+	keyAccessToken       = "access_token"
+	keyCacheTimeout      = "favorite_cache_timeout"
+	keyCacheStaleTimeout = "favorite_cache_stale_timeout"
+	keyShutdownTimeout   = "shutdown_timeout"
+	keySessionKeys       = "session_keys"
+	keySessionSecure     = "session_secure"
+	keySessionSameSite   = "session_same_site"
+	keySessionMaxAge     = "session_max_age"
+	keySessionStore      = "session_store"
+	keySessionDir        = "session_dir"
+	keyPickStrategy      = "pick_strategy"
+	keyPickTagWeights    = "pick_tag_weights"
+	keyPickHistory       = "pick_history"
+	keyPickHistoryWindow = "pick_history_window"
+	keyPickHistorySize   = "pick_history_size"
+	keyPreview           = "preview"
+	keyCacheBackend      = "cache_backend"
+	keyCachePath         = "cache_path"
+	keyCacheRedisURL     = "cache_redis_url"
+	keyCacheSize         = "cache_size"
+	keyAdminToken        = "admin_token"
 )
 
 var configs = map[string][]flags.Flag{
 	"pocket-pick": {
-		{keyBind, "B", "127.0.0.1:8000", "bind address"},
-		{keyRootURL, "r", "http://127.0.0.0:8000", "root url"},
-		{keyConsumerKey, "k", "", "getpocket consumer key"},
-		{keyAccessToken, "a", "", "getpocket access token"},
-		{keyCacheTimeout, "", time.Hour, "timeout for cache favorite items"},
+		{Name: keyBind, Shorthand: "B", DefaultValue: "127.0.0.1:8000", Usage: "bind address"},
+		{Name: keyRootURL, Shorthand: "r", DefaultValue: "http://127.0.0.0:8000", Usage: "root url"},
+		{Name: keyConsumerKey, Shorthand: "k", DefaultValue: "", Usage: "getpocket consumer key"},
+		{Name: keyAccessToken, Shorthand: "a", DefaultValue: "", Usage: "getpocket access token"},
+		{Name: keyCacheTimeout, Shorthand: "", DefaultValue: time.Hour, Usage: "timeout for cache favorite items"},
+		{Name: keyCacheStaleTimeout, Shorthand: "", DefaultValue: 24 * time.Hour, Usage: "expired favorite items are served up to the timeout while refreshing, or when refresh failed"},
+		{Name: keyShutdownTimeout, Shorthand: "", DefaultValue: 10 * time.Second, Usage: "timeout for draining requests on shutdown"},
+		{Name: keySessionKeys, Shorthand: "", DefaultValue: "", Usage: "comma-separated session keys as hashKey[:blockKey]; the first is used to sign, the rest are accepted for rotation"},
+		{Name: keySessionSecure, Shorthand: "", DefaultValue: false, Usage: "send session cookie only over https"},
+		{Name: keySessionSameSite, Shorthand: "", DefaultValue: "lax", Usage: "SameSite of session cookie: lax, strict or none"},
+		{Name: keySessionMaxAge, Shorthand: "", DefaultValue: 24 * time.Hour, Usage: "max age of session"},
+		{Name: keySessionStore, Shorthand: "", DefaultValue: "cookie", Usage: "session store: cookie, memory or file"},
+		{Name: keySessionDir, Shorthand: "", DefaultValue: "", Usage: "directory of file session store, default is pocket-pick-sessions in temp dir"},
+		{Name: keyPickStrategy, Shorthand: "", DefaultValue: "uniform", Usage: "pick strategy: uniform, oldest, recent, tags or rarely"},
+		{Name: keyPickTagWeights, Shorthand: "", DefaultValue: "", Usage: "tag weights of tags strategy such as go=3,news=0.5"},
+		{Name: keyPickHistory, Shorthand: "", DefaultValue: "window", Usage: "no-repeat mode of picks: window, shuffle or off"},
+		{Name: keyPickHistoryWindow, Shorthand: "", DefaultValue: 24 * time.Hour, Usage: "articles picked within the window are not picked again in window mode"},
+		{Name: keyPickHistorySize, Shorthand: "", DefaultValue: 100, Usage: "number of recent picks to keep"},
+		{Name: keyPreview, Shorthand: "", DefaultValue: false, Usage: "show preview page before redirecting to the picked article"},
+		{Name: keyCacheBackend, Shorthand: "", DefaultValue: "memory", Usage: "cache backend: memory, bolt or redis"},
+		{Name: keyCachePath, Shorthand: "", DefaultValue: "pocket-pick.db", Usage: "database file of bolt cache backend"},
+		{Name: keyCacheRedisURL, Shorthand: "", DefaultValue: "redis://localhost:6379/0", Usage: "url of redis cache backend"},
+		{Name: keyCacheSize, Shorthand: "", DefaultValue: 256, Usage: "max size in MB of memory cache backend"},
+		{Name: keyAdminToken, Shorthand: "", DefaultValue: "", Usage: "bearer token for /debug routes; they are disabled if empty"},
 	},
 }
 
@@ -41,3 +80,32 @@ func RootURL() string                     { return viper.GetString(keyRootURL) }
 func ConsumerKey() string                 { return viper.GetString(keyConsumerKey) }
 func AccessToken() string                 { return viper.GetString(keyAccessToken) }
 func CacheEvictionTimeout() time.Duration { return viper.GetDuration(keyCacheTimeout) }
+func CacheStaleTimeout() time.Duration    { return viper.GetDuration(keyCacheStaleTimeout) }
+func ShutdownTimeout() time.Duration      { return viper.GetDuration(keyShutdownTimeout) }
+func SessionSecure() bool                 { return viper.GetBool(keySessionSecure) }
+func SessionSameSite() string             { return viper.GetString(keySessionSameSite) }
+func SessionMaxAge() time.Duration        { return viper.GetDuration(keySessionMaxAge) }
+func SessionStore() string                { return viper.GetString(keySessionStore) }
+func SessionDir() string                  { return viper.GetString(keySessionDir) }
+func PickStrategy() string                { return viper.GetString(keyPickStrategy) }
+func PickTagWeights() string              { return viper.GetString(keyPickTagWeights) }
+func PickHistory() string                 { return viper.GetString(keyPickHistory) }
+func PickHistoryWindow() time.Duration    { return viper.GetDuration(keyPickHistoryWindow) }
+func PickHistorySize() int                { return viper.GetInt(keyPickHistorySize) }
+func Preview() bool                       { return viper.GetBool(keyPreview) }
+func CacheBackend() string                { return viper.GetString(keyCacheBackend) }
+func CachePath() string                   { return viper.GetString(keyCachePath) }
+func CacheRedisURL() string               { return viper.GetString(keyCacheRedisURL) }
+func CacheSize() int                      { return viper.GetInt(keyCacheSize) }
+func AdminToken() string                  { return viper.GetString(keyAdminToken) }
+
+// SessionKeys return session keys, the first one is the current key
+func SessionKeys() []string {
+	var keys []string
+	for _, key := range strings.Split(viper.GetString(keySessionKeys), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -1,6 +1,8 @@
 package pocket
 
 import (
+	"context"
+	"net/http"
 	"sync"
 
 	"github.com/pkg/errors"
@@ -10,25 +12,22 @@ import (
 )
 
 // CheckDeadLink ...
-func CheckDeadLink() error {
+// checking stops when ctx is done, and no articles are deleted
+func CheckDeadLink(ctx context.Context) error {
 	api := NewGetPocketAPI(config.ConsumerKey(), config.AccessToken())
-	items, err := api.Articles.Get(WithFavorate(Favorited))
-	if err != nil {
-		return errors.Wrap(err, "articles.Get(Favorite)")
-	}
-	log.Debug("items: %d", len(items))
+	it := api.Articles.Iterate(ctx, 0, WithFavorate(Favorited))
+	client := withContext(ctx, http.DefaultClient)
 
 	ch := make(chan Article)
 
 	go func() {
-		notFoundItems := []string{"274841724", "758026316", "392120428", "494194220"}
+		// items of which link was not found
+		notFoundItems := map[string]bool{"274841724": true, "758026316": true, "392120428": true, "494194220": true}
 
-		for _, v := range items {
-			// skip if link was not found
-			for _, link := range notFoundItems {
-				if v.ResolvedURL == link {
-					continue
-				}
+		for it.Next() {
+			v := it.Article()
+			if notFoundItems[v.ItemID] {
+				continue
 			}
 
 			ch <- v
@@ -38,6 +37,7 @@ func CheckDeadLink() error {
 
 	// start 4 worker
 	var itemsToDelete []string
+	var mu sync.Mutex
 	var wg sync.WaitGroup
 	for i := 0; i < 4; i++ {
 		wg.Add(1)
@@ -45,19 +45,34 @@ func CheckDeadLink() error {
 			defer wg.Done()
 
 			for article := range ch {
+				if ctx.Err() != nil {
+					continue
+				}
+
 				log.Infof("checking %s %s", article.ItemID, article.ResolvedURL)
 				resp, err := request.Get(article.ResolvedURL).
+					WithClient(client).
 					Header("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_6) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/84.0.4147.89 Safari/537.36").
 					Do()
+				if ctx.Err() != nil {
+					continue
+				}
+
 				if err != nil {
 					log.Errorf("check link failed: itemID: %s,   link: %s, err: %s", article.ItemID, article.ResolvedURL, err)
+					mu.Lock()
 					itemsToDelete = append(itemsToDelete, article.ItemID)
+					mu.Unlock()
 					continue
 				}
 
+				resp.Body.Close()
+
 				if !resp.Success() {
 					log.Errorf("failed with %d, itemID: %s, link: %s", resp.StatusCode, article.ItemID, article.ResolvedURL)
+					mu.Lock()
 					itemsToDelete = append(itemsToDelete, article.ItemID)
+					mu.Unlock()
 				}
 			}
 		}()
@@ -65,9 +80,27 @@ func CheckDeadLink() error {
 
 	wg.Wait()
 
+	if err := it.Err(); err != nil {
+		return errors.Wrap(err, "articles.Iterate(Favorite)")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if user, key, ok := api.Quota(); ok {
+		log.Infof("quota: user %d/%d, key %d/%d", user.Remaining, user.Limit, key.Remaining, key.Limit)
+	}
+
+	if len(itemsToDelete) == 0 {
+		return nil
+	}
+
 	log.Infof("deleting: %v", itemsToDelete)
 
-	if err := api.Articles.Delete(itemsToDelete...); err != nil {
+	result, err := api.Articles.Batch().Delete(itemsToDelete...).Send(ctx)
+	log.Infof("deleted %d articles", len(result.Succeeded()))
+	if err != nil {
 		return errors.Wrapf(err, "articles.Delete(%s)", itemsToDelete)
 	}
 
@@ -1,6 +1,8 @@
 package pocket
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -8,6 +10,15 @@ import (
 )
 
 func TestCheckFetchArticle(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dead" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+	defer ts.Close()
+
 	type args struct {
 		url string
 	}
@@ -18,18 +29,16 @@ func TestCheckFetchArticle(t *testing.T) {
 		wantErr     bool
 		wantSuccess bool // true if fetch success
 	}{
-		// {"", args{"https://infuture.kr/1688"}, false, true},
-		// {"", args{"https://m.biz.chosun.com/svc/article.html?contid=2016012201926"}, false, true},
-		{"", args{"http://blog.naver.com/inno_life/162500428"}, false, true},
+		{"alive", args{ts.URL + "/inno_life/162500428"}, false, true},
+		{"dead", args{ts.URL + "/dead"}, false, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			resp, err := request.Get("https://infuture.kr/1271").
+			resp, err := request.Get(tt.args.url).
 				Header("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_6) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/84.0.4147.89 Safari/537.36").
 				Do()
-			if tt.wantErr && err != nil {
-				require.Fail(t, "wantErr: %s but got success", tt.wantErr)
-				// require.True(t, resp.Success())
+			if (err != nil) != tt.wantErr {
+				require.Failf(t, "unexpected error", "wantErr: %v but got %v", tt.wantErr, err)
 			}
 
 			require.Equal(t, tt.wantSuccess, resp.Success(), "wantSuccess: %v but get status %d", tt.wantSuccess, resp.StatusCode)
@@ -0,0 +1,74 @@
+package pocket
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// debugStats statistics of the service
+type debugStats struct {
+	Cache     cacheStats     `json:"cache"`
+	Favorites favoritesStats `json:"favorites"`
+}
+
+func (s *pocketService) debugStats() debugStats {
+	return debugStats{
+		Cache:     s.cache.Stats(),
+		Favorites: s.favoritesCounters.stats(),
+	}
+}
+
+// newServiceVars metrics of the service, served with global expvars on /debug/vars
+func newServiceVars(s *pocketService) *expvar.Map {
+	vars := new(expvar.Map).Init()
+	vars.Set("cache", expvar.Func(func() interface{} { return s.debugStats() }))
+	return vars
+}
+
+// setupDebugRoute setup routes for statistics; they are disabled without admin token
+func (s *pocketService) setupDebugRoute(e *echo.Echo) {
+	if s.adminToken == "" {
+		return
+	}
+
+	debug := e.Group("/debug", s.requireAdminToken)
+	debug.GET("/cache", s.handleGetDebugCache)
+	debug.GET("/vars", s.handleGetDebugVars)
+	s.setupUserCacheRoute(debug)
+}
+
+// requireAdminToken allow requests with admin token as bearer token
+func (s *pocketService) requireAdminToken(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		auth := c.Request().Header.Get(echo.HeaderAuthorization)
+		if !strings.HasPrefix(auth, "Bearer ") ||
+			subtle.ConstantTimeCompare([]byte(strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))), []byte(s.adminToken)) != 1 {
+			return echo.NewHTTPError(http.StatusUnauthorized, "admin token required")
+		}
+
+		return next(c)
+	}
+}
+
+func (s *pocketService) handleGetDebugCache(c echo.Context) error {
+	return c.JSON(http.StatusOK, s.debugStats())
+}
+
+// handleGetDebugVars serve expvars like expvar.Handler but cmdline, which has secrets such as consumer key
+func (s *pocketService) handleGetDebugVars(c echo.Context) error {
+	vars := make(map[string]json.RawMessage)
+	collect := func(kv expvar.KeyValue) {
+		if kv.Key != "cmdline" {
+			vars[kv.Key] = json.RawMessage(kv.Value.String())
+		}
+	}
+	expvar.Do(collect)
+	s.vars.Do(collect)
+
+	return c.JSON(http.StatusOK, vars)
+}
@@ -0,0 +1,96 @@
+package pocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/whitekid/go-utils/request"
+	"github.com/whitekid/pocket-pick/pkg/pockettest"
+)
+
+const testAdminToken = "admin-token"
+
+func withAdminToken(s *pocketService) { s.adminToken = testAdminToken }
+
+func TestDebugAuth(t *testing.T) {
+	type args struct {
+		adminToken string
+		token      string
+	}
+	tests := [...]struct {
+		name     string
+		args     args
+		wantCode int
+	}{
+		{"disabled", args{"", ""}, http.StatusNotFound},
+		{"disabled with token", args{"", "any"}, http.StatusNotFound},
+		{"no token", args{testAdminToken, ""}, http.StatusUnauthorized},
+		{"invalid token", args{testAdminToken, "invalid"}, http.StatusUnauthorized},
+		{"access token", args{testAdminToken, pockettest.AccessToken}, http.StatusUnauthorized},
+		{"admin token", args{testAdminToken, testAdminToken}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, _, teardown := newTestServer(func(s *pocketService) { s.adminToken = tt.args.adminToken })
+			defer teardown()
+
+			for _, path := range []string{"/debug/cache", "/debug/vars"} {
+				req := request.Get("%s%s", ts.URL, path)
+				if tt.args.token != "" {
+					req = req.AuthBearer(tt.args.token)
+				}
+				resp, err := req.Do()
+				require.NoError(t, err)
+				require.Equal(t, tt.wantCode, resp.StatusCode, path)
+			}
+		})
+	}
+}
+
+func TestDebugCache(t *testing.T) {
+	ts, pocketServer, teardown := newTestServer(withAdminToken)
+	defer teardown()
+
+	pocketServer.AddItems(pockettest.Item{GivenURL: "https://golang.org/", Favorite: "1"})
+
+	for i := 0; i < 2; i++ {
+		resp, err := request.Get("%s/api/v1/pick", ts.URL).AuthBearer(pockettest.AccessToken).Do()
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	resp, err := request.Get("%s/debug/cache", ts.URL).AuthBearer(testAdminToken).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var stats debugStats
+	require.NoError(t, resp.JSON(&stats))
+	require.Equal(t, CacheMemory, stats.Cache.Backend)
+	require.NotZero(t, stats.Cache.Hits)
+	require.NotZero(t, stats.Cache.Misses)
+	require.NotZero(t, stats.Cache.Entries)
+	require.NotNil(t, stats.Cache.BigCache)
+	require.Equal(t, int64(1), stats.Favorites.Refreshes)
+	require.Equal(t, int64(0), stats.Favorites.RefreshFailures)
+}
+
+func TestDebugVars(t *testing.T) {
+	ts, _, teardown := newTestServer(withAdminToken)
+	defer teardown()
+
+	resp, err := request.Get("%s/debug/vars", ts.URL).AuthBearer(testAdminToken).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var vars map[string]json.RawMessage
+	require.NoError(t, resp.JSON(&vars))
+	require.Contains(t, vars, "memstats")
+	require.Contains(t, vars, "cache")
+	require.NotContains(t, vars, "cmdline", "cmdline has secrets")
+
+	var stats debugStats
+	require.NoError(t, json.Unmarshal(vars["cache"], &stats))
+	require.Equal(t, CacheMemory, stats.Cache.Backend)
+}
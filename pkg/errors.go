@@ -0,0 +1,86 @@
+package pocket
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/whitekid/go-utils/request"
+)
+
+// errors of pocket api; use errors.Is() to check the error
+// see https://getpocket.com/developer/docs/errors
+var (
+	ErrMissingConsumerKey = errors.New("missing consumer key")
+	ErrInvalidConsumerKey = errors.New("invalid consumer key")
+	ErrMissingAccessToken = errors.New("missing access token")
+	ErrInvalidAccessToken = errors.New("invalid access token")
+	ErrMissingRedirectURL = errors.New("missing redirect url")
+	ErrInvalidRedirectURI = errors.New("invalid redirect uri")
+	ErrMissingCode        = errors.New("missing code")
+	ErrCodeNotFound       = errors.New("code not found")
+	ErrUserRejectedCode   = errors.New("user rejected code")
+	ErrAlreadyUsedCode    = errors.New("already used code")
+	ErrRateLimited        = errors.New("rate limited")
+	ErrServerError        = errors.New("pocket server error")
+	ErrActionFailed       = errors.New("action failed") // pocket could not apply action, such as the item does not exist
+)
+
+// errorCodes maps X-Error-Code to errors
+var errorCodes = map[int]error{
+	107: ErrInvalidAccessToken,
+	138: ErrMissingConsumerKey,
+	140: ErrMissingRedirectURL,
+	152: ErrInvalidConsumerKey,
+	158: ErrUserRejectedCode,
+	159: ErrAlreadyUsedCode,
+	181: ErrInvalidRedirectURI,
+	182: ErrMissingCode,
+	185: ErrCodeNotFound,
+	199: ErrServerError,
+}
+
+// Error error response of pocket api; use errors.As() to get details
+type Error struct {
+	StatusCode int           // http status code
+	Code       int           // X-Error-Code, 0 if not given
+	Message    string        // X-Error
+	RetryAfter time.Duration // Retry-After if given
+	err        error         // one of Err* errors, nil if unknown
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("error with status: %d, error=%s, code=%d", e.StatusCode, e.Message, e.Code)
+}
+
+// Unwrap return matched Err* error
+func (e *Error) Unwrap() error { return e.err }
+
+// newError create Error from failed response
+func newError(r *request.Response) *Error {
+	e := &Error{
+		StatusCode: r.StatusCode,
+		Message:    r.Header.Get("X-Error"),
+		RetryAfter: retryAfter(r),
+	}
+	e.Code, _ = strconv.Atoi(r.Header.Get("X-Error-Code"))
+
+	if err, ok := errorCodes[e.Code]; ok {
+		e.err = err
+		return e
+	}
+
+	switch {
+	case r.StatusCode == http.StatusTooManyRequests,
+		r.StatusCode == http.StatusForbidden && retryable(r):
+		e.err = ErrRateLimited
+	case r.StatusCode == http.StatusUnauthorized:
+		e.err = ErrInvalidAccessToken
+	case r.StatusCode >= http.StatusInternalServerError:
+		e.err = ErrServerError
+	}
+
+	return e
+}
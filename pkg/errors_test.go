@@ -0,0 +1,88 @@
+package pocket
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/whitekid/pocket-pick/pkg/pockettest"
+)
+
+func TestErrors(t *testing.T) {
+	type args struct {
+		fault pockettest.Fault
+	}
+	tests := [...]struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{"invalid consumer key", args{pockettest.Fault{StatusCode: http.StatusForbidden, ErrorCode: 152, Error: "Invalid consumer key."}}, ErrInvalidConsumerKey},
+		{"missing consumer key", args{pockettest.Fault{StatusCode: http.StatusBadRequest, ErrorCode: 138}}, ErrMissingConsumerKey},
+		{"invalid access token", args{pockettest.Fault{StatusCode: http.StatusUnauthorized, ErrorCode: 107}}, ErrInvalidAccessToken},
+		{"unauthorized", args{pockettest.Fault{StatusCode: http.StatusUnauthorized}}, ErrInvalidAccessToken},
+		{"user rejected", args{pockettest.Fault{StatusCode: http.StatusForbidden, ErrorCode: 158}}, ErrUserRejectedCode},
+		{"rate limited", args{pockettest.Fault{StatusCode: http.StatusTooManyRequests}}, ErrRateLimited},
+		{"throttled", args{pockettest.Fault{StatusCode: http.StatusForbidden, Header: http.Header{"X-Limit-User-Limit": {"320"}, "X-Limit-User-Remaining": {"0"}}}}, ErrRateLimited},
+		{"server error", args{pockettest.Fault{StatusCode: http.StatusServiceUnavailable, ErrorCode: 199}}, ErrServerError},
+		{"internal error", args{pockettest.Fault{StatusCode: http.StatusInternalServerError}}, ErrServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pocketServer := pockettest.NewServer()
+			defer pocketServer.Close()
+			pocketServer.InjectFault(tt.args.fault)
+
+			api := newTestAPI(pocketServer, pockettest.AccessToken, WithRetry(0, 0))
+			_, err := api.Articles.Get(context.Background())
+			require.Error(t, err)
+			require.True(t, errors.Is(err, tt.wantErr), "want %v but got %v", tt.wantErr, err)
+
+			var apiErr *Error
+			require.True(t, errors.As(err, &apiErr))
+			require.Equal(t, tt.args.fault.StatusCode, apiErr.StatusCode)
+			require.Equal(t, tt.args.fault.ErrorCode, apiErr.Code)
+			require.Equal(t, tt.args.fault.Error, apiErr.Message)
+		})
+	}
+}
+
+func TestErrorRetryAfter(t *testing.T) {
+	pocketServer := pockettest.NewServer()
+	defer pocketServer.Close()
+	pocketServer.InjectFault(pockettest.Fault{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"30"}}})
+
+	api := newTestAPI(pocketServer, pockettest.AccessToken, WithRetry(0, 0))
+	_, err := api.Articles.Get(context.Background())
+
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, time.Second*30, apiErr.RetryAfter)
+}
+
+func TestErrorMissingAccessToken(t *testing.T) {
+	pocketServer := pockettest.NewServer()
+	defer pocketServer.Close()
+
+	api := newTestAPI(pocketServer, "")
+	_, err := api.Articles.Get(context.Background())
+	require.True(t, errors.Is(err, ErrMissingAccessToken))
+	require.True(t, errors.Is(api.Articles.Delete(context.Background(), "1"), ErrMissingAccessToken))
+	require.Equal(t, 0, pocketServer.RequestCount("/v3/get"))
+}
+
+func TestErrorRejected(t *testing.T) {
+	pocketServer := pockettest.NewServer()
+	defer pocketServer.Close()
+
+	api := newTestAPI(pocketServer, "")
+	token, _, err := api.AuthorizedURL(context.Background(), "http://127.0.0.1/auth")
+	require.NoError(t, err)
+
+	// user did not approve yet
+	_, _, err = api.NewAccessToken(context.Background(), token)
+	require.True(t, errors.Is(err, ErrUserRejectedCode), "error=%v", err)
+}
@@ -0,0 +1,320 @@
+package pocket
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/whitekid/go-utils/log"
+	"github.com/whitekid/pocket-pick/pkg/config"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// favoritesRefreshTimeout timeout of refreshing favorites; refresh is not canceled by requests
+	favoritesRefreshTimeout = time.Minute
+
+	// favoritesRetryInterval stale favorites are served without refresh for the interval after refresh failed
+	favoritesRetryInterval = 5 * time.Minute
+)
+
+// favoritesStats statistics of favorites refreshes
+type favoritesStats struct {
+	Refreshes       int64 `json:"refreshes"`
+	RefreshFailures int64 `json:"refresh_failures"`
+	StaleHits       int64 `json:"stale_hits"` // stale articles served while refreshing
+}
+
+// favoritesCounters counters of favorites refreshes
+type favoritesCounters struct {
+	refreshes       int64
+	refreshFailures int64
+	staleHits       int64
+}
+
+func (c *favoritesCounters) stats() favoritesStats {
+	return favoritesStats{
+		Refreshes:       atomic.LoadInt64(&c.refreshes),
+		RefreshFailures: atomic.LoadInt64(&c.refreshFailures),
+		StaleHits:       atomic.LoadInt64(&c.staleHits),
+	}
+}
+
+// favoritesEntry cached favorite articles of the user
+type favoritesEntry struct {
+	Fetched    time.Time          `json:"fetched"`
+	Articles   map[string]Article `json:"articles"`
+	RetryAfter time.Time          `json:"retry_after"` // refresh is not tried until the time after failed
+}
+
+// fresh return true if articles were fetched within favorite cache timeout
+func (e *favoritesEntry) fresh(now time.Time) bool {
+	return now.Sub(e.Fetched) < config.CacheEvictionTimeout()
+}
+
+// cachedFavorites return cached favorites of the user; they could be stale
+func (s *pocketService) cachedFavorites(accessToken string) (*favoritesEntry, bool) {
+	data, exists := s.cache.Get([]byte(favoritesCacheKey(accessToken)))
+	if !exists {
+		return nil, false
+	}
+
+	var entry favoritesEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		log.Errorf("invalid favorites cache: %s", err)
+		return nil, false
+	}
+
+	return &entry, true
+}
+
+// favoriteArticles return favorite articles of the user
+// fresh cache is returned as is, stale cache is returned while refreshing in background
+// concurrent refreshes of the same user are merged to one
+func (s *pocketService) favoriteArticles(ctx context.Context, accessToken string) (map[string]Article, error) {
+	now := time.Now()
+	entry, exists := s.cachedFavorites(accessToken)
+	if exists && entry.fresh(now) {
+		log.Debug("load articles from cache")
+		return entry.Articles, nil
+	}
+
+	if exists && now.Before(entry.RetryAfter) {
+		atomic.AddInt64(&s.favoritesCounters.staleHits, 1)
+		log.Debugf("load stale articles fetched at %s, refresh is retried after %s", entry.Fetched, entry.RetryAfter)
+		return entry.Articles, nil
+	}
+
+	ch := s.startRefresh(accessToken)
+
+	if exists {
+		atomic.AddInt64(&s.favoritesCounters.staleHits, 1)
+		log.Debugf("load stale articles fetched at %s while refreshing", entry.Fetched)
+		return entry.Articles, nil
+	}
+
+	select {
+	case r := <-ch:
+		if r.Err != nil {
+			return nil, r.Err
+		}
+		return r.Val.(map[string]Article), nil
+
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// startRefresh refresh favorites of the user in background like DoChan of singleflight,
+// and track it so that shutdown waits for it before closing the cache
+func (s *pocketService) startRefresh(accessToken string) <-chan singleflight.Result {
+	ch := make(chan singleflight.Result, 1)
+
+	s.refreshes.Add(1)
+	go func() {
+		defer s.refreshes.Done()
+
+		v, err, shared := s.refreshing.Do(favoritesCacheKey(accessToken), func() (interface{}, error) {
+			return s.refreshFavorites(accessToken)
+		})
+		ch <- singleflight.Result{Val: v, Err: err, Shared: shared}
+	}()
+
+	return ch
+}
+
+// waitRefreshes wait for refreshes of favorites in background until ctx is done
+func (s *pocketService) waitRefreshes(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.refreshes.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "wait for refreshing favorites")
+	}
+}
+
+// refreshFavorites sync favorite articles of the user and cache them
+// if sync failed, the last good copy is returned and refresh is not tried for retry interval
+// changes of articles while syncing are applied again, so that they are not overwritten by the sync
+func (s *pocketService) refreshFavorites(accessToken string) (map[string]Article, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), favoritesRefreshTimeout)
+	defer cancel()
+
+	s.pendingChanges.start(accessToken)
+	atomic.AddInt64(&s.favoritesCounters.refreshes, 1)
+	articles, err := s.favorites.Sync(ctx, s.newAPI(accessToken), favoritesSyncKey(accessToken))
+
+	unlock := s.favoritesLocks.lock(accessToken)
+	defer unlock()
+
+	changes := s.pendingChanges.finish(accessToken)
+	if err != nil {
+		atomic.AddInt64(&s.favoritesCounters.refreshFailures, 1)
+		if entry, exists := s.cachedFavorites(accessToken); exists {
+			log.Errorf("refresh favorites failed, use articles fetched at %s: %s", entry.Fetched, err)
+			entry.RetryAfter = time.Now().Add(favoritesRetryInterval)
+			if err := s.saveFavorites(accessToken, entry); err != nil {
+				log.Errorf("cache favorites failed: %s", err)
+			}
+			return entry.Articles, nil
+		}
+		return nil, errors.Wrap(err, "get favorite artcles failed")
+	}
+	log.Debugf("you have %d articles", len(articles))
+
+	invalidated := false
+	for _, change := range changes {
+		if _, known := articles[change.itemID]; !known && change.action == ActionFavorite {
+			invalidated = true
+		}
+		change.apply(articles)
+	}
+
+	// favorited article while syncing is unknown until the next sync
+	if invalidated {
+		return articles, nil
+	}
+
+	if err := s.saveFavorites(accessToken, &favoritesEntry{Fetched: time.Now(), Articles: articles}); err != nil {
+		log.Errorf("cache favorites failed: %s", err)
+	}
+
+	return articles, nil
+}
+
+// saveFavorites cache favorites of the user
+// stale articles are kept up to stale timeout to serve them while refreshing
+func (s *pocketService) saveFavorites(accessToken string, entry *favoritesEntry) error {
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "json encode failed")
+	}
+
+	ttl := time.Until(entry.Fetched.Add(config.CacheEvictionTimeout() + config.CacheStaleTimeout()))
+	return s.cache.Set([]byte(favoritesCacheKey(accessToken)), buf, withTTL(ttl))
+}
+
+// articleChange change of an article by an action
+type articleChange struct {
+	itemID string
+	action string
+	tags   []string
+}
+
+// apply apply the change to the article; return false if the article is unknown or the action does not change it
+func (a *articleChange) apply(articles map[string]Article) bool {
+	article, ok := articles[a.itemID]
+	if !ok {
+		return false
+	}
+
+	switch a.action {
+	case ActionDelete, ActionUnfavorite:
+		delete(articles, a.itemID)
+		return true
+
+	case ActionArchive:
+		article.Status, article.TimeRead = ArticleArchived, time.Now()
+
+	case ActionReadd:
+		article.Status, article.TimeRead = ArticleUnread, time.Time{}
+
+	case ActionTagsAdd, ActionTagsRemove, ActionTagsReplace, ActionTagsClear:
+		if a.action == ActionTagsReplace || a.action == ActionTagsClear || article.Tags == nil {
+			article.Tags = make(map[string]Tag)
+		}
+		for _, tag := range a.tags {
+			switch a.action {
+			case ActionTagsAdd, ActionTagsReplace:
+				article.Tags[tag] = Tag{ItemID: a.itemID, Tag: tag}
+			case ActionTagsRemove:
+				delete(article.Tags, tag)
+			}
+		}
+
+	default:
+		return false
+	}
+
+	articles[a.itemID] = article
+	return true
+}
+
+// pendingChanges changes of articles while refreshing favorites of each user
+type pendingChanges struct {
+	mu      sync.Mutex
+	changes map[string][]articleChange
+}
+
+// start record changes of the user until finish
+func (p *pendingChanges) start(accessToken string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.changes == nil {
+		p.changes = make(map[string][]articleChange)
+	}
+	p.changes[accessToken] = []articleChange{}
+}
+
+// record record the change if favorites of the user are refreshing
+func (p *pendingChanges) record(accessToken string, change articleChange) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if changes, ok := p.changes[accessToken]; ok {
+		p.changes[accessToken] = append(changes, change)
+	}
+}
+
+// finish stop recording and return recorded changes of the user
+func (p *pendingChanges) finish(accessToken string) []articleChange {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changes := p.changes[accessToken]
+	delete(p.changes, accessToken)
+	return changes
+}
+
+// articleChanged apply action on the article to cached favorites of the user,
+// so that deleted or unfavorited articles are not picked until the next refresh
+func (s *pocketService) articleChanged(accessToken, itemID, action string, tags ...string) {
+	unlock := s.favoritesLocks.lock(accessToken)
+	defer unlock()
+
+	change := articleChange{itemID: itemID, action: action, tags: tags}
+	s.pendingChanges.record(accessToken, change)
+
+	entry, exists := s.cachedFavorites(accessToken)
+	if !exists {
+		return
+	}
+
+	if _, known := entry.Articles[itemID]; !known {
+		// favorited article is unknown until the next sync
+		if action == ActionFavorite {
+			if err := s.cache.Delete([]byte(favoritesCacheKey(accessToken))); err != nil {
+				log.Errorf("invalidate favorites failed: %s", err)
+			}
+		}
+		return
+	}
+
+	if !change.apply(entry.Articles) {
+		return
+	}
+
+	if err := s.saveFavorites(accessToken, entry); err != nil {
+		log.Errorf("update favorites failed: %s", err)
+	}
+}
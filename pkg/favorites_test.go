@@ -0,0 +1,258 @@
+package pocket
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/whitekid/pocket-pick/pkg/pockettest"
+)
+
+// newTestFavorites create service with fake pocket server to test favorites
+func newTestFavorites() (*pocketService, *pockettest.Server, func()) {
+	var service *pocketService
+	_, pocketServer, teardown := newTestServer(func(s *pocketService) { service = s })
+	return service, pocketServer, teardown
+}
+
+// setStaleFavorites put favorites fetched before age to cache
+func setStaleFavorites(t *testing.T, s *pocketService, accessToken string, age time.Duration, articles map[string]Article) {
+	buf, err := json.Marshal(&favoritesEntry{Fetched: time.Now().Add(-age), Articles: articles})
+	require.NoError(t, err)
+	require.NoError(t, s.cache.Set([]byte(favoritesCacheKey(accessToken)), buf))
+}
+
+func TestFavoritesSingleFlight(t *testing.T) {
+	s, pocketServer, teardown := newTestFavorites()
+	defer teardown()
+
+	ids := pocketServer.AddItems(pockettest.Item{GivenURL: "https://golang.org/", Favorite: "1"})
+	pocketServer.InjectFault(pockettest.Fault{Path: "/v3/get", Delay: 200 * time.Millisecond, Times: 1})
+
+	type result struct {
+		articles map[string]Article
+		err      error
+	}
+	results := make(chan result, 10)
+	for i := 0; i < cap(results); i++ {
+		go func() {
+			articles, err := s.favoriteArticles(context.Background(), pockettest.AccessToken)
+			results <- result{articles, err}
+		}()
+	}
+	for i := 0; i < cap(results); i++ {
+		r := <-results
+		require.NoError(t, r.err)
+		require.Contains(t, r.articles, ids[0])
+	}
+	concurrent := pocketServer.RequestCount("/v3/get")
+
+	// requests of a single sync
+	s.purgeUserCache(pockettest.AccessToken)
+	_, err := s.favoriteArticles(context.Background(), pockettest.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, concurrent, pocketServer.RequestCount("/v3/get")-concurrent)
+}
+
+func TestFavoritesStaleWhileRevalidate(t *testing.T) {
+	s, pocketServer, teardown := newTestFavorites()
+	defer teardown()
+
+	ids := pocketServer.AddItems(pockettest.Item{GivenURL: "https://golang.org/", Favorite: "1"})
+	setStaleFavorites(t, s, pockettest.AccessToken, 2*time.Hour, map[string]Article{"stale": {ItemID: "stale"}})
+	pocketServer.InjectFault(pockettest.Fault{Path: "/v3/get", Delay: time.Second, Times: 1})
+
+	// stale articles are served without waiting for slow pocket
+	start := time.Now()
+	articles, err := s.favoriteArticles(context.Background(), pockettest.AccessToken)
+	require.NoError(t, err)
+	require.Less(t, int64(time.Since(start)), int64(500*time.Millisecond))
+	require.Contains(t, articles, "stale")
+
+	require.Eventually(t, func() bool {
+		entry, exists := s.cachedFavorites(pockettest.AccessToken)
+		return exists && entry.fresh(time.Now())
+	}, 5*time.Second, 50*time.Millisecond)
+
+	articles, err = s.favoriteArticles(context.Background(), pockettest.AccessToken)
+	require.NoError(t, err)
+	require.Contains(t, articles, ids[0])
+	require.NotContains(t, articles, "stale")
+}
+
+func TestFavoritesRefreshFailed(t *testing.T) {
+	s, pocketServer, teardown := newTestFavorites()
+	defer teardown()
+
+	pocketServer.AddItems(pockettest.Item{GivenURL: "https://golang.org/", Favorite: "1"})
+	pocketServer.InjectFault(pockettest.Fault{Path: "/v3/get", StatusCode: http.StatusUnauthorized, ErrorCode: 107})
+
+	// no copy to fall back
+	_, err := s.favoriteArticles(context.Background(), pockettest.AccessToken)
+	require.Error(t, err)
+
+	// the last good copy is used
+	setStaleFavorites(t, s, pockettest.AccessToken, 2*time.Hour, map[string]Article{"stale": {ItemID: "stale"}})
+	articles, err := s.refreshFavorites(pockettest.AccessToken)
+	require.NoError(t, err)
+	require.Contains(t, articles, "stale")
+
+	entry, exists := s.cachedFavorites(pockettest.AccessToken)
+	require.True(t, exists)
+	require.False(t, entry.fresh(time.Now()), "failed refresh should not renew the cache")
+	require.True(t, entry.RetryAfter.After(time.Now()))
+
+	// refresh is not tried again until retry after
+	requests := pocketServer.RequestCount("/v3/get")
+	articles, err = s.favoriteArticles(context.Background(), pockettest.AccessToken)
+	require.NoError(t, err)
+	require.Contains(t, articles, "stale")
+	s.refreshes.Wait()
+	require.Equal(t, requests, pocketServer.RequestCount("/v3/get"))
+
+	// refresh is tried after retry after
+	entry.RetryAfter = time.Now().Add(-time.Second)
+	require.NoError(t, s.saveFavorites(pockettest.AccessToken, entry))
+	_, err = s.favoriteArticles(context.Background(), pockettest.AccessToken)
+	require.NoError(t, err)
+	s.refreshes.Wait()
+	require.Less(t, requests, pocketServer.RequestCount("/v3/get"))
+}
+
+func TestFavoritesCanceled(t *testing.T) {
+	s, pocketServer, teardown := newTestFavorites()
+	defer teardown()
+
+	pocketServer.AddItems(pockettest.Item{GivenURL: "https://golang.org/", Favorite: "1"})
+	pocketServer.InjectFault(pockettest.Fault{Path: "/v3/get", Delay: 500 * time.Millisecond, Times: 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := s.favoriteArticles(ctx, pockettest.AccessToken)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// refresh is not canceled by the request
+	require.Eventually(t, func() bool {
+		_, exists := s.cachedFavorites(pockettest.AccessToken)
+		return exists
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestArticleChanged(t *testing.T) {
+	type args struct {
+		itemID string
+		action string
+		tags   []string
+	}
+	tests := [...]struct {
+		name        string
+		args        args
+		wantExists  bool
+		wantArticle func(t *testing.T, articles map[string]Article)
+	}{
+		{"delete", args{"1", ActionDelete, nil}, true,
+			func(t *testing.T, articles map[string]Article) { require.NotContains(t, articles, "1") }},
+		{"unfavorite", args{"1", ActionUnfavorite, nil}, true,
+			func(t *testing.T, articles map[string]Article) { require.NotContains(t, articles, "1") }},
+		{"archive", args{"1", ActionArchive, nil}, true,
+			func(t *testing.T, articles map[string]Article) {
+				require.Equal(t, ArticleArchived, articles["1"].Status)
+				require.False(t, articles["1"].TimeRead.IsZero())
+			}},
+		{"readd", args{"2", ActionReadd, nil}, true,
+			func(t *testing.T, articles map[string]Article) { require.Equal(t, ArticleUnread, articles["2"].Status) }},
+		{"tags add", args{"1", ActionTagsAdd, []string{"lang"}}, true,
+			func(t *testing.T, articles map[string]Article) {
+				require.Equal(t, []string{"go", "lang"}, articleTags(articles["1"]))
+			}},
+		{"tags remove", args{"1", ActionTagsRemove, []string{"go"}}, true,
+			func(t *testing.T, articles map[string]Article) {
+				require.Equal(t, []string{}, articleTags(articles["1"]))
+			}},
+		{"tags replace", args{"1", ActionTagsReplace, []string{"golang"}}, true,
+			func(t *testing.T, articles map[string]Article) {
+				require.Equal(t, []string{"golang"}, articleTags(articles["1"]))
+			}},
+		{"tags clear", args{"1", ActionTagsClear, nil}, true,
+			func(t *testing.T, articles map[string]Article) {
+				require.Equal(t, []string{}, articleTags(articles["1"]))
+			}},
+		{"favorite known", args{"1", ActionFavorite, nil}, true,
+			func(t *testing.T, articles map[string]Article) { require.Equal(t, 2, len(articles)) }},
+		{"favorite unknown", args{"3", ActionFavorite, nil}, false, nil},
+		{"delete unknown", args{"3", ActionDelete, nil}, true,
+			func(t *testing.T, articles map[string]Article) { require.Equal(t, 2, len(articles)) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _, teardown := newTestFavorites()
+			defer teardown()
+
+			fetched := time.Now().Add(-time.Minute)
+			require.NoError(t, s.saveFavorites(pockettest.AccessToken, &favoritesEntry{Fetched: fetched, Articles: map[string]Article{
+				"1": {ItemID: "1", Status: ArticleUnread, Tags: map[string]Tag{"go": {ItemID: "1", Tag: "go"}}},
+				"2": {ItemID: "2", Status: ArticleArchived},
+			}}))
+
+			s.articleChanged(pockettest.AccessToken, tt.args.itemID, tt.args.action, tt.args.tags...)
+
+			entry, exists := s.cachedFavorites(pockettest.AccessToken)
+			require.Equal(t, tt.wantExists, exists)
+			if !tt.wantExists {
+				return
+			}
+			require.True(t, fetched.Equal(entry.Fetched), "changes should not renew the cache")
+			tt.wantArticle(t, entry.Articles)
+		})
+	}
+}
+
+func TestArticleChangedWhileRefreshing(t *testing.T) {
+	s, pocketServer, teardown := newTestFavorites()
+	defer teardown()
+
+	ids := pocketServer.AddItems(
+		pockettest.Item{GivenURL: "https://golang.org/", Favorite: "1"},
+		pockettest.Item{GivenURL: "https://go.dev/", Favorite: "1"},
+	)
+	setStaleFavorites(t, s, pockettest.AccessToken, 2*time.Hour, map[string]Article{
+		ids[0]: {ItemID: ids[0]},
+		ids[1]: {ItemID: ids[1]},
+	})
+	pocketServer.InjectFault(pockettest.Fault{Path: "/v3/get", Delay: 300 * time.Millisecond, Times: 1})
+
+	refreshed := make(chan map[string]Article, 1)
+	go func() {
+		articles, _ := s.refreshFavorites(pockettest.AccessToken)
+		refreshed <- articles
+	}()
+
+	// article is deleted after the sync fetched it
+	require.Eventually(t, func() bool { return pocketServer.RequestCount("/v3/get") > 0 }, time.Second, 10*time.Millisecond)
+	s.articleChanged(pockettest.AccessToken, ids[0], ActionDelete)
+
+	articles := <-refreshed
+	require.NotContains(t, articles, ids[0])
+	require.Contains(t, articles, ids[1])
+
+	entry, exists := s.cachedFavorites(pockettest.AccessToken)
+	require.True(t, exists)
+	require.True(t, entry.fresh(time.Now()))
+	require.NotContains(t, entry.Articles, ids[0], "refresh should not bring back the deleted article")
+	require.Contains(t, entry.Articles, ids[1])
+}
+
+// articleTags sorted tags of the article
+func articleTags(a Article) []string {
+	tags := []string{}
+	for tag := range a.Tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
@@ -0,0 +1,142 @@
+package pocket
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// wordsPerMinute reading speed to estimate minutes when pocket does not give time to read
+const wordsPerMinute = 200
+
+// pickFilter narrows candidates of random pick
+type pickFilter struct {
+	Tag         string `json:"tag,omitempty"`         // tag, or TagUntagged
+	Domain      string `json:"domain,omitempty"`      // domain including its subdomains
+	State       string `json:"state,omitempty"`       // StateUnread, StateArchive or StateAll
+	MaxMinutes  int    `json:"max_minutes,omitempty"` // max minutes to read, 0 for no limit
+	ContentType string `json:"type,omitempty"`        // ContentTypeArticle, ContentTypeVideo or ContentTypeImage
+}
+
+// parsePickFilter parse filter from query parameters: tag, domain, state, max_minutes and type
+func parsePickFilter(values url.Values) (pickFilter, error) {
+	f := pickFilter{
+		Tag:         values.Get("tag"),
+		Domain:      strings.TrimPrefix(strings.ToLower(values.Get("domain")), "www."),
+		State:       values.Get("state"),
+		ContentType: values.Get("type"),
+	}
+
+	switch f.State {
+	case "", StateUnread, StateArchive, StateAll:
+	default:
+		return f, fmt.Errorf("invalid state: %s", f.State)
+	}
+
+	switch f.ContentType {
+	case "", ContentTypeArticle, ContentTypeVideo, ContentTypeImage:
+	default:
+		return f, fmt.Errorf("invalid type: %s", f.ContentType)
+	}
+
+	if v := values.Get("max_minutes"); v != "" {
+		minutes, err := strconv.Atoi(v)
+		if err != nil || minutes < 0 {
+			return f, fmt.Errorf("invalid max_minutes: %s", v)
+		}
+		f.MaxMinutes = minutes
+	}
+
+	return f, nil
+}
+
+// IsZero return true if filter matches all articles
+func (f pickFilter) IsZero() bool { return f == pickFilter{} }
+
+// Match return true if article matches all conditions of filter
+func (f pickFilter) Match(a Article) bool {
+	switch f.Tag {
+	case "":
+	case TagUntagged:
+		if len(a.Tags) > 0 {
+			return false
+		}
+	default:
+		if !a.HasTag(f.Tag) {
+			return false
+		}
+	}
+
+	if f.Domain != "" {
+		domain := a.Domain()
+		if domain != f.Domain && !strings.HasSuffix(domain, "."+f.Domain) {
+			return false
+		}
+	}
+
+	switch f.State {
+	case StateUnread:
+		if a.Status != ArticleUnread {
+			return false
+		}
+	case StateArchive:
+		if a.Status != ArticleArchived {
+			return false
+		}
+	}
+
+	// articles of unknown length are not short
+	if f.MaxMinutes > 0 {
+		minutes := minutesToRead(a)
+		if minutes == 0 || minutes > f.MaxMinutes {
+			return false
+		}
+	}
+
+	switch f.ContentType {
+	case ContentTypeArticle:
+		if !a.IsArticle {
+			return false
+		}
+	case ContentTypeVideo:
+		if a.HasVideo == MediaNone {
+			return false
+		}
+	case ContentTypeImage:
+		if a.HasImage == MediaNone {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Filter return articles which match filter
+func (f pickFilter) Filter(articles map[string]Article) map[string]Article {
+	if f.IsZero() {
+		return articles
+	}
+
+	matched := make(map[string]Article)
+	for id, article := range articles {
+		if f.Match(article) {
+			matched[id] = article
+		}
+	}
+
+	return matched
+}
+
+// minutesToRead return time to read given by pocket, or estimate it from word count; 0 if unknown
+func minutesToRead(a Article) int {
+	if a.TimeToRead > 0 {
+		return a.TimeToRead
+	}
+
+	if a.WordCount > 0 {
+		return (a.WordCount + wordsPerMinute - 1) / wordsPerMinute
+	}
+
+	return 0
+}
@@ -0,0 +1,90 @@
+package pocket
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePickFilter(t *testing.T) {
+	type args struct {
+		query string
+	}
+	tests := [...]struct {
+		name    string
+		args    args
+		want    pickFilter
+		wantErr bool
+	}{
+		{"empty", args{""}, pickFilter{}, false},
+		{"all", args{"tag=go&domain=www.Medium.com&state=unread&max_minutes=10&type=video"},
+			pickFilter{Tag: "go", Domain: "medium.com", State: StateUnread, MaxMinutes: 10, ContentType: ContentTypeVideo}, false},
+		{"invalid state", args{"state=deleted"}, pickFilter{}, true},
+		{"invalid type", args{"type=audio"}, pickFilter{}, true},
+		{"invalid max_minutes", args{"max_minutes=short"}, pickFilter{}, true},
+		{"negative max_minutes", args{"max_minutes=-1"}, pickFilter{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.args.query)
+			require.NoError(t, err)
+
+			got, err := parsePickFilter(values)
+			if (err != nil) != tt.wantErr {
+				require.Failf(t, "parsePickFilter() failed", "error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPickFilterMatch(t *testing.T) {
+	article := Article{
+		ItemID:      "1",
+		ResolvedURL: "https://blog.medium.com/go",
+		Status:      ArticleUnread,
+		WordCount:   1000,
+		IsArticle:   true,
+		HasVideo:    MediaHas,
+		Tags:        map[string]Tag{"go": {ItemID: "1", Tag: "go"}},
+	}
+
+	type args struct {
+		filter  pickFilter
+		article Article
+	}
+	tests := [...]struct {
+		name string
+		args args
+		want bool
+	}{
+		{"empty", args{pickFilter{}, article}, true},
+		{"tag", args{pickFilter{Tag: "go"}, article}, true},
+		{"tag not matched", args{pickFilter{Tag: "rust"}, article}, false},
+		{"untagged", args{pickFilter{Tag: TagUntagged}, article}, false},
+		{"untagged matched", args{pickFilter{Tag: TagUntagged}, Article{}}, true},
+		{"domain", args{pickFilter{Domain: "blog.medium.com"}, article}, true},
+		{"subdomain", args{pickFilter{Domain: "medium.com"}, article}, true},
+		{"domain not matched", args{pickFilter{Domain: "dium.com"}, article}, false},
+		{"unread", args{pickFilter{State: StateUnread}, article}, true},
+		{"archive", args{pickFilter{State: StateArchive}, article}, false},
+		{"all", args{pickFilter{State: StateAll}, article}, true},
+		{"estimated minutes", args{pickFilter{MaxMinutes: 5}, article}, true},
+		{"too long", args{pickFilter{MaxMinutes: 4}, article}, false},
+		{"time to read", args{pickFilter{MaxMinutes: 4}, Article{TimeToRead: 3, WordCount: 1000}}, true},
+		{"unknown length", args{pickFilter{MaxMinutes: 10}, Article{}}, false},
+		{"article", args{pickFilter{ContentType: ContentTypeArticle}, article}, true},
+		{"video", args{pickFilter{ContentType: ContentTypeVideo}, article}, true},
+		{"image", args{pickFilter{ContentType: ContentTypeImage}, article}, false},
+		{"all conditions", args{pickFilter{Tag: "go", Domain: "medium.com", State: StateUnread, MaxMinutes: 10, ContentType: ContentTypeVideo}, article}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.args.filter.Match(tt.args.article))
+		})
+	}
+}
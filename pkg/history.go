@@ -0,0 +1,173 @@
+package pocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/whitekid/go-utils/log"
+)
+
+// modes of pick history
+const (
+	HistoryWindow  = "window"  // do not repeat articles picked within the window
+	HistoryShuffle = "shuffle" // do not repeat articles until every article is picked once
+	HistoryOff     = "off"     // articles could be picked repeatedly
+)
+
+// historyPolicy how pick history excludes articles
+type historyPolicy struct {
+	mode   string
+	window time.Duration // window of HistoryWindow
+	size   int           // max number of recent picks to keep
+}
+
+func newHistoryPolicy(mode string, window time.Duration, size int) (historyPolicy, error) {
+	switch mode {
+	case HistoryWindow, HistoryShuffle, HistoryOff:
+	case "":
+		mode = HistoryOff
+	default:
+		return historyPolicy{}, fmt.Errorf("unknown pick history mode: %s", mode)
+	}
+
+	return historyPolicy{mode: mode, window: window, size: size}, nil
+}
+
+// pickHistory recent picks of a user
+type pickHistory struct {
+	Picks []pickRecord    `json:"picks"` // most recent first
+	Bag   map[string]bool `json:"bag"`   // articles picked in current round of shuffle
+}
+
+type pickRecord struct {
+	ItemID string    `json:"item_id"`
+	Time   time.Time `json:"time"`
+}
+
+// candidates return articles which were not picked recently
+// if every article was picked, window mode returns all articles and shuffle mode starts new round
+func (p historyPolicy) candidates(h *pickHistory, articles map[string]Article, now time.Time) map[string]Article {
+	excluded := make(map[string]bool)
+
+	switch p.mode {
+	case HistoryWindow:
+		for _, pick := range h.Picks {
+			if now.Sub(pick.Time) < p.window {
+				excluded[pick.ItemID] = true
+			}
+		}
+
+	case HistoryShuffle:
+		for itemID := range h.Bag {
+			excluded[itemID] = true
+		}
+
+	default:
+		return articles
+	}
+
+	candidates := make(map[string]Article)
+	for id, article := range articles {
+		if !excluded[id] {
+			candidates[id] = article
+		}
+	}
+
+	if len(candidates) == 0 {
+		if p.mode == HistoryShuffle {
+			log.Debugf("every article was picked, start new round")
+			h.Bag = nil
+		}
+		return articles
+	}
+
+	return candidates
+}
+
+// record add pick to history
+func (p historyPolicy) record(h *pickHistory, itemID string, now time.Time) {
+	h.Picks = append([]pickRecord{{ItemID: itemID, Time: now}}, h.Picks...)
+	if p.size > 0 && len(h.Picks) > p.size {
+		h.Picks = h.Picks[:p.size]
+	}
+
+	if p.mode == HistoryShuffle {
+		if h.Bag == nil {
+			h.Bag = make(map[string]bool)
+		}
+		h.Bag[itemID] = true
+	}
+}
+
+// historyKey cache key of pick history of the user
+func historyKey(accessToken string) string {
+	return fmt.Sprintf("%s/history", accessToken)
+}
+
+func (s *pocketService) loadHistory(accessToken string) *pickHistory {
+	var h pickHistory
+	if data, exists := s.cache.Get([]byte(historyKey(accessToken))); exists {
+		if err := json.Unmarshal(data, &h); err != nil {
+			log.Errorf("invalid pick history: %s", err)
+		}
+	}
+
+	return &h
+}
+
+// saveHistory save history of the user; caller should hold pick lock of the user from loading the history
+func (s *pocketService) saveHistory(accessToken string, h *pickHistory) {
+	buf, err := json.Marshal(h)
+	if err != nil {
+		log.Errorf("json encode failed: %s", err)
+		return
+	}
+
+	if err := s.cache.Set([]byte(historyKey(accessToken)), buf); err != nil {
+		log.Errorf("save pick history failed: %s", err)
+	}
+}
+
+// historyEntry recent pick returned by /history
+type historyEntry struct {
+	ItemID string    `json:"item_id"`
+	Time   time.Time `json:"time"`
+	Title  string    `json:"title,omitempty"`
+	URL    string    `json:"url,omitempty"`
+}
+
+// handleGetHistory list recent picks
+func (s *pocketService) handleGetHistory(c echo.Context) error {
+	var accessToken string
+	if err := s.requireAccessToken(c, &accessToken); err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+	}
+
+	// add title and url if articles are cached
+	var articles map[string]Article
+	if entry, exists := s.cachedFavorites(accessToken); exists {
+		articles = entry.Articles
+	}
+
+	h := s.loadHistory(accessToken)
+	entries := make([]historyEntry, 0, len(h.Picks))
+	for _, pick := range h.Picks {
+		entry := historyEntry{ItemID: pick.ItemID, Time: pick.Time}
+		if article, ok := articles[pick.ItemID]; ok {
+			entry.Title = article.ResolvedTitle
+			if entry.Title == "" {
+				entry.Title = article.GivenTitle
+			}
+			entry.URL = article.ResolvedURL
+			if entry.URL == "" {
+				entry.URL = article.GivenURL
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	return c.JSON(http.StatusOK, entries)
+}
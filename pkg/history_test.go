@@ -0,0 +1,123 @@
+package pocket
+
+import (
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/whitekid/go-utils/request"
+	"github.com/whitekid/pocket-pick/pkg/pockettest"
+)
+
+func candidateIDs(articles map[string]Article) []string {
+	ids := make([]string, 0, len(articles))
+	for id := range articles {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestHistoryPolicy(t *testing.T) {
+	now := time.Now()
+	articles := map[string]Article{"1": {ItemID: "1"}, "2": {ItemID: "2"}, "3": {ItemID: "3"}}
+
+	type args struct {
+		mode   string
+		window time.Duration
+		picks  []string // picked in order, an hour apart
+	}
+	tests := [...]struct {
+		name string
+		args args
+		want []string
+	}{
+		{"off", args{HistoryOff, time.Hour, []string{"1", "2"}}, []string{"1", "2", "3"}},
+		{"window", args{HistoryWindow, 30 * time.Minute, []string{"1", "2"}}, []string{"1", "3"}},
+		{"window all picked", args{HistoryWindow, 3 * time.Hour, []string{"3", "2", "1"}}, []string{"1", "2", "3"}},
+		{"shuffle", args{HistoryShuffle, 0, []string{"1", "2"}}, []string{"3"}},
+		{"shuffle all picked", args{HistoryShuffle, 0, []string{"1", "2", "3"}}, []string{"1", "2", "3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newHistoryPolicy(tt.args.mode, tt.args.window, 10)
+			require.NoError(t, err)
+
+			h := &pickHistory{}
+			for i, id := range tt.args.picks {
+				p.record(h, id, now.Add(time.Duration(i-len(tt.args.picks)+1)*time.Hour))
+			}
+
+			require.Equal(t, tt.want, candidateIDs(p.candidates(h, articles, now)))
+		})
+	}
+}
+
+func TestHistoryShuffleRound(t *testing.T) {
+	p, err := newHistoryPolicy(HistoryShuffle, 0, 10)
+	require.NoError(t, err)
+
+	now := time.Now()
+	articles := map[string]Article{"1": {ItemID: "1"}, "2": {ItemID: "2"}}
+	h := &pickHistory{}
+
+	p.record(h, "1", now)
+	p.record(h, "2", now)
+	require.Equal(t, []string{"1", "2"}, candidateIDs(p.candidates(h, articles, now)))
+
+	// new round started
+	p.record(h, "2", now)
+	require.Equal(t, []string{"1"}, candidateIDs(p.candidates(h, articles, now)))
+}
+
+func TestHistorySize(t *testing.T) {
+	p, err := newHistoryPolicy(HistoryWindow, time.Hour, 2)
+	require.NoError(t, err)
+
+	h := &pickHistory{}
+	for _, id := range []string{"1", "2", "3"} {
+		p.record(h, id, time.Now())
+	}
+
+	require.Equal(t, 2, len(h.Picks))
+	require.Equal(t, "3", h.Picks[0].ItemID)
+	require.Equal(t, "2", h.Picks[1].ItemID)
+}
+
+func TestUnknownHistoryMode(t *testing.T) {
+	_, err := newHistoryPolicy("unknown", 0, 0)
+	require.Error(t, err)
+}
+
+func TestHistoryConcurrentPicks(t *testing.T) {
+	var service *pocketService
+	ts, pocketServer, teardown := newTestServer(func(s *pocketService) {
+		service = s
+		s.history = historyPolicy{mode: HistoryOff, size: 100}
+	})
+	defer teardown()
+
+	ids := pocketServer.AddItems(pockettest.Item{GivenURL: "https://golang.org/", Favorite: "1"})
+
+	codes := make(chan int, 20)
+	for i := 0; i < cap(codes); i++ {
+		go func() {
+			resp, err := request.Get("%s/api/v1/pick", ts.URL).AuthBearer(pockettest.AccessToken).Do()
+			if err != nil {
+				codes <- 0
+				return
+			}
+			resp.Body.Close()
+			codes <- resp.StatusCode
+		}()
+	}
+	for i := 0; i < cap(codes); i++ {
+		require.Equal(t, http.StatusOK, <-codes)
+	}
+
+	// no picks are lost by concurrent updates
+	require.Equal(t, cap(codes), len(service.loadHistory(pockettest.AccessToken).Picks))
+	require.Equal(t, map[string]int{ids[0]: cap(codes)}, service.pickCounts(pockettest.AccessToken))
+}
@@ -0,0 +1,126 @@
+package pocket
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultPageSize number of articles fetched by a request when iterating
+const DefaultPageSize = 100
+
+// ArticleIterator iterate articles page by page using count/offset
+//
+//	it := api.Articles.Iterate(ctx, 0, WithFavorate(Favorited))
+//	for it.Next() {
+//		article := it.Article()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+type ArticleIterator struct {
+	api      *ArticlesAPI
+	ctx      context.Context
+	opts     []GetOption
+	pageSize int
+	offset   int
+
+	page    []Article
+	article Article
+	since   int64 // since of the first page
+	done    bool
+	err     error
+}
+
+// Iterate return iterator of articles that yields articles one at a time
+// pageSize is number of articles for a request, DefaultPageSize if 0
+// articles are iterated from the oldest unless WithSort() was given,
+// so items that added while iterating does not shift pages
+func (a *ArticlesAPI) Iterate(ctx context.Context, pageSize int, opts ...GetOption) *ArticleIterator {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	return &ArticleIterator{
+		api:      a,
+		ctx:      ctx,
+		opts:     append([]GetOption{WithSort(SortOldest)}, opts...),
+		pageSize: pageSize,
+	}
+}
+
+// Next advance to next article, return false if there is no more articles or error occurred
+func (it *ArticleIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+
+	if len(it.page) == 0 {
+		if it.done {
+			return false
+		}
+
+		if err := it.fetch(); err != nil {
+			it.err = err
+			return false
+		}
+
+		if len(it.page) == 0 {
+			return false
+		}
+	}
+
+	it.article, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+func (it *ArticleIterator) fetch() error {
+	opts := append(append([]GetOption{}, it.opts...), WithCount(it.pageSize), WithOffset(it.offset))
+	response, err := it.api.get(it.ctx, opts...)
+	if err != nil {
+		return errors.Wrapf(err, "Get(offset=%d)", it.offset)
+	}
+
+	if it.since == 0 {
+		it.since = response.Since
+	}
+
+	var items map[string]Article
+	if response.List != nil {
+		items = *response.List
+	}
+
+	it.page = make([]Article, 0, len(items))
+	for _, item := range items {
+		it.page = append(it.page, item)
+	}
+	sort.Slice(it.page, func(i, j int) bool { return it.page[i].SortID < it.page[j].SortID })
+
+	// pocket may return fewer items than count, so only an empty page is the end
+	it.offset += len(items)
+	if len(items) == 0 {
+		it.done = true
+	}
+
+	return nil
+}
+
+// Article return current article
+func (it *ArticleIterator) Article() Article { return it.article }
+
+// Since return server time of the first page; pass it to WithSince() to get changes after the iteration
+func (it *ArticleIterator) Since() time.Time {
+	if it.since == 0 {
+		return time.Time{}
+	}
+	return time.Unix(it.since, 0)
+}
+
+// Err return error occurred while iterating
+func (it *ArticleIterator) Err() error { return it.err }
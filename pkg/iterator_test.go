@@ -0,0 +1,80 @@
+package pocket
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/whitekid/pocket-pick/pkg/pockettest"
+)
+
+func TestIterate(t *testing.T) {
+	pocketServer := pockettest.NewServer()
+	defer pocketServer.Close()
+
+	for i := 0; i < 25; i++ {
+		pocketServer.AddItems(pockettest.Item{
+			ItemID:    strconv.Itoa(1000 + i),
+			GivenURL:  "https://golang.org/" + strconv.Itoa(i),
+			TimeAdded: strconv.Itoa(1000 + i),
+			Favorite:  strconv.Itoa(i % 2),
+		})
+	}
+
+	type args struct {
+		pageSize int
+		maxCount int
+		opts     []GetOption
+	}
+	tests := [...]struct {
+		name         string
+		args         args
+		wantCount    int
+		wantRequests int
+	}{
+		{"default", args{0, 0, nil}, 25, 2},
+		{"paging", args{10, 0, nil}, 25, 4},
+		{"exact page", args{5, 0, nil}, 25, 6},
+		{"favorite", args{10, 0, []GetOption{WithFavorate(Favorited)}}, 12, 3},
+		{"fewer than count", args{10, 7, nil}, 25, 5},
+		{"fewer than default", args{0, 30, nil}, 25, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pocketServer.SetMaxCount(tt.args.maxCount)
+			before := pocketServer.RequestCount("/v3/get")
+
+			api := newTestAPI(pocketServer, pockettest.AccessToken)
+			it := api.Articles.Iterate(context.Background(), tt.args.pageSize, tt.args.opts...)
+
+			var ids []string
+			for it.Next() {
+				ids = append(ids, it.Article().ItemID)
+			}
+			require.NoError(t, it.Err())
+			require.Equal(t, tt.wantCount, len(ids))
+			require.Equal(t, tt.wantRequests, pocketServer.RequestCount("/v3/get")-before)
+
+			// articles are yielded from the oldest
+			for i := 1; i < len(ids); i++ {
+				require.True(t, ids[i-1] < ids[i], "%s < %s", ids[i-1], ids[i])
+			}
+		})
+	}
+}
+
+func TestIterateCancel(t *testing.T) {
+	pocketServer := pockettest.NewServer()
+	defer pocketServer.Close()
+	pocketServer.AddItems(pockettest.Item{GivenURL: "https://golang.org/"}, pockettest.Item{GivenURL: "https://go.dev/"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	api := newTestAPI(pocketServer, pockettest.AccessToken)
+	it := api.Articles.Iterate(ctx, 1)
+
+	require.True(t, it.Next())
+	cancel()
+	require.False(t, it.Next())
+	require.Equal(t, context.Canceled, it.Err())
+}
@@ -0,0 +1,41 @@
+package pocket
+
+import "sync"
+
+// userLocks mutex of each user; locks are released when nobody holds them
+type userLocks struct {
+	mu    sync.Mutex
+	locks map[string]*userLock
+}
+
+type userLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock lock the key and return the function to unlock
+func (l *userLocks) lock(key string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*userLock)
+	}
+	lock, ok := l.locks[key]
+	if !ok {
+		lock = &userLock{}
+		l.locks[key] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	lock.Lock()
+
+	return func() {
+		lock.Unlock()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if lock.refs--; lock.refs == 0 {
+			delete(l.locks, key)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package pocket
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserLocks(t *testing.T) {
+	var locks userLocks
+	var wg sync.WaitGroup
+	counts := map[string]*int{"a": new(int), "b": new(int)} // counters of each key are guarded by its lock
+
+	for i := 0; i < 100; i++ {
+		for _, key := range []string{"a", "b"} {
+			wg.Add(1)
+			go func(key string) {
+				defer wg.Done()
+
+				unlock := locks.lock(key)
+				defer unlock()
+				v := *counts[key]
+				*counts[key] = v + 1
+			}(key)
+		}
+	}
+	wg.Wait()
+
+	require.Equal(t, 100, *counts["a"])
+	require.Equal(t, 100, *counts["b"])
+	require.Empty(t, locks.locks, "released locks are removed")
+}
@@ -1,9 +1,22 @@
 package pocket
 
+import (
+	"net/http"
+	"time"
+)
+
 type GetOptions struct {
-	search   string // Only return items whose title or url contain the search string
-	domain   string // Only return items from a particular domain
-	favorite int    // only return favorited items
+	search      string    // Only return items whose title or url contain the search string
+	domain      string    // Only return items from a particular domain
+	favorite    int       // only return favorited items
+	state       string    // unread, archive or all
+	tag         string    // only return items tagged with tag; _untagged_ for untagged items
+	contentType string    // article, video or image
+	sort        string    // newest, oldest, title or site
+	detailType  string    // simple or complete
+	since       time.Time // only return items modified since the given time
+	count       int       // only return count number of items
+	offset      int       // used only with count; start returning from offset position of results
 }
 
 type GetOption interface {
@@ -38,3 +51,139 @@ func WithFavorate(favorate int) GetOption {
 		o.favorite = favorate
 	})
 }
+
+// WithState only return items of given state: StateUnread, StateArchive or StateAll
+func WithState(state string) GetOption {
+	return newFuncGetOption(func(o *GetOptions) {
+		o.state = state
+	})
+}
+
+// WithTag only return items tagged with tag; use TagUntagged for untagged items
+func WithTag(tag string) GetOption {
+	return newFuncGetOption(func(o *GetOptions) {
+		o.tag = tag
+	})
+}
+
+// WithContentType only return items of given type: ContentTypeArticle, ContentTypeVideo or ContentTypeImage
+func WithContentType(contentType string) GetOption {
+	return newFuncGetOption(func(o *GetOptions) {
+		o.contentType = contentType
+	})
+}
+
+// WithSort sort items by SortNewest, SortOldest, SortTitle or SortSite
+func WithSort(sort string) GetOption {
+	return newFuncGetOption(func(o *GetOptions) {
+		o.sort = sort
+	})
+}
+
+// WithDetailType return items with DetailTypeSimple or DetailTypeComplete
+func WithDetailType(detailType string) GetOption {
+	return newFuncGetOption(func(o *GetOptions) {
+		o.detailType = detailType
+	})
+}
+
+// WithSince only return items modified since the given time
+func WithSince(since time.Time) GetOption {
+	return newFuncGetOption(func(o *GetOptions) {
+		o.since = since
+	})
+}
+
+// WithCount only return count number of items
+func WithCount(count int) GetOption {
+	return newFuncGetOption(func(o *GetOptions) {
+		o.count = count
+	})
+}
+
+// WithOffset start returning from offset position of results, used only with WithCount
+func WithOffset(offset int) GetOption {
+	return newFuncGetOption(func(o *GetOptions) {
+		o.offset = offset
+	})
+}
+
+// APIOptions options for GetPocketAPI
+type APIOptions struct {
+	apiURL       string        // base url of v3 api
+	authorizeURL string        // user authorization url
+	client       *http.Client  // http client for api calls
+	userAgent    string        // User-Agent header
+	rateLimits   *RateLimits   // shared quota tracker
+	maxRetries   int           // max retries of throttled requests
+	retryBackoff time.Duration // initial backoff of retry
+	maxRetryWait time.Duration // max wait for a retry
+}
+
+// APIOption option for NewGetPocketAPI
+type APIOption interface {
+	apply(*APIOptions)
+}
+
+type funcAPIOption struct {
+	f func(o *APIOptions)
+}
+
+func (f *funcAPIOption) apply(o *APIOptions) { f.f(o) }
+
+func newFuncAPIOption(f func(o *APIOptions)) APIOption {
+	return &funcAPIOption{
+		f: f,
+	}
+}
+
+// WithAPIURL set base url of v3 api, default is https://getpocket.com/v3
+func WithAPIURL(apiURL string) APIOption {
+	return newFuncAPIOption(func(o *APIOptions) {
+		o.apiURL = apiURL
+	})
+}
+
+// WithAuthorizeURL set url where user authorize request token, default is https://getpocket.com/auth/authorize
+func WithAuthorizeURL(authorizeURL string) APIOption {
+	return newFuncAPIOption(func(o *APIOptions) {
+		o.authorizeURL = authorizeURL
+	})
+}
+
+// WithHTTPClient set http client for api calls
+func WithHTTPClient(client *http.Client) APIOption {
+	return newFuncAPIOption(func(o *APIOptions) {
+		o.client = client
+	})
+}
+
+// WithUserAgent set User-Agent header for api calls
+func WithUserAgent(userAgent string) APIOption {
+	return newFuncAPIOption(func(o *APIOptions) {
+		o.userAgent = userAgent
+	})
+}
+
+// WithRateLimits share quota tracker between api instances
+func WithRateLimits(rateLimits *RateLimits) APIOption {
+	return newFuncAPIOption(func(o *APIOptions) {
+		o.rateLimits = rateLimits
+	})
+}
+
+// WithRetry retry throttled requests maxRetries times with backoff which doubled for each retry
+// Retry-After header is respected if it is longer than backoff; maxRetries 0 disable retry
+func WithRetry(maxRetries int, backoff time.Duration) APIOption {
+	return newFuncAPIOption(func(o *APIOptions) {
+		o.maxRetries = maxRetries
+		o.retryBackoff = backoff
+	})
+}
+
+// WithMaxRetryWait set max wait for a retry or until exhausted quota is reset, default is a minute
+func WithMaxRetryWait(wait time.Duration) APIOption {
+	return newFuncAPIOption(func(o *APIOptions) {
+		o.maxRetryWait = wait
+	})
+}
@@ -0,0 +1,185 @@
+package pocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/whitekid/go-utils/log"
+)
+
+// pick strategies
+const (
+	PickUniform = "uniform" // every article has the same chance
+	PickOldest  = "oldest"  // older articles are picked more often
+	PickRecent  = "recent"  // recently added articles are picked more often
+	PickTags    = "tags"    // articles are weighted by tag weights
+	PickRarely  = "rarely"  // articles picked less are picked more often
+)
+
+// picker choose an article from candidates with weights
+type picker struct {
+	weight func(a Article) float64
+	rand   *rand.Rand // nil to use default source
+}
+
+// pickState states that strategies need to weight articles
+type pickState struct {
+	now        time.Time
+	counts     map[string]int     // item id -> number of picks
+	tagWeights map[string]float64 // tag -> weight
+}
+
+// newPicker create picker of strategy
+func newPicker(strategy string, state pickState) (*picker, error) {
+	var weight func(a Article) float64
+
+	switch strategy {
+	case PickUniform, "":
+		weight = func(a Article) float64 { return 1 }
+
+	case PickOldest:
+		weight = func(a Article) float64 { return 1 + ageInDays(state.now, a) }
+
+	case PickRecent:
+		weight = func(a Article) float64 { return 1 / (1 + ageInDays(state.now, a)) }
+
+	case PickTags:
+		weight = func(a Article) float64 {
+			w, matched := 0.0, false
+			for tag := range a.Tags {
+				if tw, ok := state.tagWeights[tag]; ok && (!matched || tw > w) {
+					w, matched = tw, true
+				}
+			}
+			if !matched {
+				return 1
+			}
+			return w
+		}
+
+	case PickRarely:
+		weight = func(a Article) float64 { return 1 / float64(1+state.counts[a.ItemID]) }
+
+	default:
+		return nil, fmt.Errorf("unknown pick strategy: %s", strategy)
+	}
+
+	return &picker{weight: weight}, nil
+}
+
+// Pick choose an article by weights; false if no article could be picked
+func (p *picker) Pick(articles map[string]Article) (Article, bool) {
+	ids := make([]string, 0, len(articles))
+	for id := range articles {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	weights := make([]float64, len(ids))
+	total := 0.0
+	for i, id := range ids {
+		if w := p.weight(articles[id]); w > 0 {
+			weights[i] = w
+			total += w
+		}
+	}
+
+	if total == 0 {
+		return Article{}, false
+	}
+
+	r := p.float64() * total
+	for i, id := range ids {
+		if r < weights[i] {
+			return articles[id], true
+		}
+		r -= weights[i]
+	}
+
+	// floating point error, pick the last weighted one
+	for i := len(ids) - 1; i >= 0; i-- {
+		if weights[i] > 0 {
+			return articles[ids[i]], true
+		}
+	}
+
+	return Article{}, false
+}
+
+func (p *picker) float64() float64 {
+	if p.rand != nil {
+		return p.rand.Float64()
+	}
+	return rand.Float64()
+}
+
+// ageInDays days since article was added
+func ageInDays(now time.Time, a Article) float64 {
+	if a.TimeAdded.IsZero() || a.TimeAdded.After(now) {
+		return 0
+	}
+	return now.Sub(a.TimeAdded).Hours() / 24
+}
+
+// parseTagWeights parse tag weights as tag=weight separated by comma such as "go=3,news=0.5"
+func parseTagWeights(value string) (map[string]float64, error) {
+	weights := make(map[string]float64)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid tag weight: %s", pair)
+		}
+
+		w, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("invalid tag weight: %s", pair)
+		}
+		weights[parts[0]] = w
+	}
+
+	return weights, nil
+}
+
+// pickCounts return number of picks of each article of the user
+func (s *pocketService) pickCounts(accessToken string) map[string]int {
+	counts := make(map[string]int)
+	if data, exists := s.cache.Get([]byte(pickCountsKey(accessToken))); exists {
+		if err := json.Unmarshal(data, &counts); err != nil {
+			log.Errorf("invalid pick counts: %s", err)
+		}
+	}
+
+	return counts
+}
+
+// recordPick increase pick count of the article; counts of articles which are not in articles are removed
+// caller should hold pick lock of the user
+func (s *pocketService) recordPick(accessToken, itemID string, articles map[string]Article) {
+	counts := s.pickCounts(accessToken)
+	for id := range counts {
+		if _, ok := articles[id]; !ok {
+			delete(counts, id)
+		}
+	}
+	counts[itemID]++
+
+	buf, err := json.Marshal(counts)
+	if err != nil {
+		log.Errorf("json encode failed: %s", err)
+		return
+	}
+
+	if err := s.cache.Set([]byte(pickCountsKey(accessToken)), buf); err != nil {
+		log.Errorf("save pick counts failed: %s", err)
+	}
+}
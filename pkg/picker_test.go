@@ -0,0 +1,140 @@
+package pocket
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPickerWeight(t *testing.T) {
+	now := time.Now()
+	old := Article{ItemID: "1", TimeAdded: now.Add(-99 * 24 * time.Hour), Tags: map[string]Tag{"go": {}, "news": {}}}
+	recent := Article{ItemID: "2", TimeAdded: now}
+
+	state := pickState{
+		now:        now,
+		counts:     map[string]int{"1": 3},
+		tagWeights: map[string]float64{"go": 3, "news": 0.5},
+	}
+
+	type args struct {
+		strategy string
+		article  Article
+	}
+	tests := [...]struct {
+		name string
+		args args
+		want float64
+	}{
+		{"uniform", args{PickUniform, old}, 1},
+		{"default", args{"", old}, 1},
+		{"oldest", args{PickOldest, old}, 100},
+		{"oldest recent", args{PickOldest, recent}, 1},
+		{"recent", args{PickRecent, old}, 0.01},
+		{"recent recent", args{PickRecent, recent}, 1},
+		{"tags", args{PickTags, old}, 3},
+		{"tags untagged", args{PickTags, recent}, 1},
+		{"rarely", args{PickRarely, old}, 0.25},
+		{"rarely never picked", args{PickRarely, recent}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newPicker(tt.args.strategy, state)
+			require.NoError(t, err)
+			require.InDelta(t, tt.want, p.weight(tt.args.article), 0.0001)
+		})
+	}
+}
+
+func TestPickerUnknownStrategy(t *testing.T) {
+	_, err := newPicker("unknown", pickState{})
+	require.Error(t, err)
+}
+
+func TestPickerPick(t *testing.T) {
+	now := time.Now()
+	articles := map[string]Article{
+		"old":    {ItemID: "old", TimeAdded: now.Add(-999 * 24 * time.Hour)},
+		"recent": {ItemID: "recent", TimeAdded: now},
+	}
+
+	p, err := newPicker(PickOldest, pickState{now: now})
+	require.NoError(t, err)
+	p.rand = rand.New(rand.NewSource(1))
+
+	picks := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		article, ok := p.Pick(articles)
+		require.True(t, ok)
+		picks[article.ItemID]++
+	}
+	require.Greater(t, picks["old"], 950)
+
+	// nothing to pick
+	_, ok := p.Pick(map[string]Article{})
+	require.False(t, ok)
+
+	p, err = newPicker(PickTags, pickState{now: now, tagWeights: map[string]float64{"skip": 0}})
+	require.NoError(t, err)
+	_, ok = p.Pick(map[string]Article{"1": {ItemID: "1", Tags: map[string]Tag{"skip": {}}}})
+	require.False(t, ok)
+}
+
+func TestParseTagWeights(t *testing.T) {
+	type args struct {
+		value string
+	}
+	tests := [...]struct {
+		name    string
+		args    args
+		want    map[string]float64
+		wantErr bool
+	}{
+		{"empty", args{""}, map[string]float64{}, false},
+		{"weights", args{"go=3, news=0.5"}, map[string]float64{"go": 3, "news": 0.5}, false},
+		{"missing weight", args{"go"}, nil, true},
+		{"invalid weight", args{"go=many"}, nil, true},
+		{"negative weight", args{"go=-1"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTagWeights(tt.args.value)
+			if (err != nil) != tt.wantErr {
+				require.Failf(t, "parseTagWeights() failed", "error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRecordPick(t *testing.T) {
+	s, _, teardown := newTestFavorites()
+	defer teardown()
+
+	articles := map[string]Article{"1": {ItemID: "1"}, "2": {ItemID: "2"}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(itemID string) {
+			defer wg.Done()
+
+			unlock := s.pickLocks.lock("token")
+			defer unlock()
+			s.recordPick("token", itemID, articles)
+		}([]string{"1", "2"}[i%2])
+	}
+	wg.Wait()
+	require.Equal(t, map[string]int{"1": 25, "2": 25}, s.pickCounts("token"))
+
+	// counts of removed articles are pruned
+	delete(articles, "1")
+	s.recordPick("token", "2", articles)
+	require.Equal(t, map[string]int{"2": 26}, s.pickCounts("token"))
+}
@@ -2,11 +2,15 @@ package pocket
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"math/rand"
+	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -18,9 +22,16 @@ import (
 // GetPocketAPI get pocket api
 // please refer https://getpocket.com/developer/docs/overview
 type GetPocketAPI struct {
-	consumerKey string
-	accessToken string
-	sess        request.Interface // common sessions
+	consumerKey  string
+	accessToken  string
+	apiURL       string        // base url of v3 api, without trailing slash
+	authorizeURL string        // url which user authorize the request token
+	userAgent    string        // User-Agent header, leave empty to use default
+	client       *http.Client  // http client for api calls
+	rateLimits   *RateLimits   // quota of users and consumer keys
+	maxRetries   int           // max retries of throttled requests
+	retryBackoff time.Duration // initial backoff of retry, doubled for each retry
+	maxRetryWait time.Duration // max wait for a retry or exhausted quota
 
 	// API interfaces
 	Articles *ArticlesAPI
@@ -30,12 +41,46 @@ func init() {
 	rand.Seed(time.Now().UnixNano())
 }
 
+const (
+	defaultAPIURL       = "https://getpocket.com/v3"
+	defaultAuthorizeURL = "https://getpocket.com/auth/authorize"
+	defaultMaxRetries   = 3
+	defaultRetryBackoff = time.Second
+	defaultMaxRetryWait = time.Minute
+)
+
 // NewGetPocketAPI create GetPocket API
-func NewGetPocketAPI(consumerKey, accessToken string) *GetPocketAPI {
+func NewGetPocketAPI(consumerKey, accessToken string, opts ...APIOption) *GetPocketAPI {
+	apiOpts := APIOptions{
+		apiURL:       defaultAPIURL,
+		authorizeURL: defaultAuthorizeURL,
+		maxRetries:   defaultMaxRetries,
+		retryBackoff: defaultRetryBackoff,
+		maxRetryWait: defaultMaxRetryWait,
+	}
+	for _, o := range opts {
+		o.apply(&apiOpts)
+	}
+
+	if apiOpts.client == nil {
+		apiOpts.client = &http.Client{}
+	}
+
+	if apiOpts.rateLimits == nil {
+		apiOpts.rateLimits = NewRateLimits()
+	}
+
 	api := &GetPocketAPI{
-		consumerKey: consumerKey,
-		accessToken: accessToken,
-		sess:        request.NewSession(nil),
+		consumerKey:  consumerKey,
+		accessToken:  accessToken,
+		apiURL:       strings.TrimRight(apiOpts.apiURL, "/"),
+		authorizeURL: apiOpts.authorizeURL,
+		userAgent:    apiOpts.userAgent,
+		client:       apiOpts.client,
+		rateLimits:   apiOpts.rateLimits,
+		maxRetries:   apiOpts.maxRetries,
+		retryBackoff: apiOpts.retryBackoff,
+		maxRetryWait: apiOpts.maxRetryWait,
 	}
 
 	api.Articles = &ArticlesAPI{pocket: api}
@@ -43,58 +88,54 @@ func NewGetPocketAPI(consumerKey, accessToken string) *GetPocketAPI {
 	return api
 }
 
-// Article pocket article, see https://getpocket.com/developer/docs/v3/retrieve
-type Article struct {
-	ItemID        string `json:"item_id"`
-	ResolvedID    string `json:"resolved_id"`
-	GivenURL      string `json:"given_url"`
-	GivelTitle    string `json:"given_title"`
-	Favorite      string `json:"favorite"`
-	Status        string `json:"status"`
-	ResolvedTitle string `json:"resolved_title"`
-	ResolvedURL   string `json:"resolved_url"`
-	Excerpt       string `json:"excerpt"`
-	IsArticle     string `json:"is_article"`
-	HasVideo      string `json:"has_video"`
-	HasImage      string `json:"has_image"`
-	WordCount     string `json:"word_count"`
-	Images        map[string]struct {
-		ItemID  string `json:"item_id"`
-		ImageID string `json:"image_id"`
-		Src     string `json:"src"`
-		Width   string `json:"width"`
-		Height  string `json:"height"`
-		Credit  string `json:"credit"`
-		Caption string `json:"caption"`
-	} `json:"images"`
-	Videos map[string]struct {
-		ItemID  string `json:"item_id"`
-		VideoID string `json:"video_id"`
-		Src     string `json:"src"`
-		Width   string `json:"width"`
-		Height  string `json:"height"`
-		Type    string `json:"type"`
-		Vid     string `json:"vid"`
-	} `json:"videos"`
+// contextTransport send requests with context
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
+
+// withContext return copy of http client which sends requests with ctx
+func withContext(ctx context.Context, client *http.Client) *http.Client {
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	c := *client
+	c.Transport = &contextTransport{ctx: ctx, base: base}
+	return &c
+}
+
+// post prepare POST request to api endpoint; path is relative to api url such as "/get"
+func (g *GetPocketAPI) post(ctx context.Context, path string) *request.Request {
+	req := request.Post(g.apiURL+path).
+		WithClient(withContext(ctx, g.client)).
+		Header("X-"+echo.HeaderAccept, echo.MIMEApplicationJSON)
+	if g.userAgent != "" {
+		req.Header("User-Agent", g.userAgent)
+	}
+
+	return req
 }
 
 func (g *GetPocketAPI) success(r *request.Response) error {
 	if r.Success() {
 		return nil
 	}
-	message := r.Header.Get("x-error")
-	code := r.Header.Get("x-error-code")
-	return fmt.Errorf("error with status: %d, error=%s, code=%s", r.StatusCode, message, code)
+	return newError(r)
 }
 
 // AuthorizedURL get authorizedURL
-func (g *GetPocketAPI) AuthorizedURL(redirectURI string) (string, string, error) {
-	resp, err := request.Post("https://getpocket.com/v3/oauth/request").
-		Header("X-"+echo.HeaderAccept, echo.MIMEApplicationJSON).
+func (g *GetPocketAPI) AuthorizedURL(ctx context.Context, redirectURI string) (string, string, error) {
+	resp, err := g.do(ctx, g.post(ctx, "/oauth/request").
 		JSON(map[string]string{
 			"consumer_key": g.consumerKey,
 			"redirect_uri": redirectURI,
-		}).Do()
+		}))
 
 	if err != nil {
 		return "", "", err
@@ -112,25 +153,24 @@ func (g *GetPocketAPI) AuthorizedURL(redirectURI string) (string, string, error)
 		return "", "", err
 	}
 
-	return response.Code, fmt.Sprintf("https://getpocket.com/auth/authorize?request_token=%s&redirect_uri=%s", response.Code, redirectURI), nil
+	return response.Code, fmt.Sprintf("%s?request_token=%s&redirect_uri=%s", g.authorizeURL, url.QueryEscape(response.Code), url.QueryEscape(redirectURI)), nil
 }
 
 // NewAccessToken get accessToken, username from requestToken using oauth
-func (g *GetPocketAPI) NewAccessToken(requestToken string) (string, string, error) {
+func (g *GetPocketAPI) NewAccessToken(ctx context.Context, requestToken string) (string, string, error) {
 	log.Debugf("getAccessToken with %s", requestToken)
 
-	resp, err := g.sess.Post("https://getpocket.com/v3/oauth/authorize").
-		Header("X-"+echo.HeaderAccept, echo.MIMEApplicationJSON).
+	resp, err := g.do(ctx, g.post(ctx, "/oauth/authorize").
 		JSON(map[string]string{
 			"consumer_key": g.consumerKey,
 			"code":         requestToken,
-		}).Do()
+		}))
 	if err != nil {
 		return "", "", err
 	}
 
 	if err := g.success(resp); err != nil {
-		return "", "", fmt.Errorf("failed with status: %d", resp.StatusCode)
+		return "", "", errors.Wrap(err, "NewAccessToken failed")
 	}
 
 	var response struct {
@@ -154,26 +194,80 @@ const (
 	Favorited   = 2 // only return favorited items
 )
 
+// values for WithState
+const (
+	StateUnread  = "unread"  // only return unread items
+	StateArchive = "archive" // only return archived items
+	StateAll     = "all"     // return both unread and archived items
+)
+
+// TagUntagged only return untagged items with WithTag
+const TagUntagged = "_untagged_"
+
+// values for WithContentType
+const (
+	ContentTypeArticle = "article" // only return articles
+	ContentTypeVideo   = "video"   // only return videos or articles with embedded videos
+	ContentTypeImage   = "image"   // only return images
+)
+
+// values for WithSort
+const (
+	SortNewest = "newest" // return items in order of newest to oldest
+	SortOldest = "oldest" // return items in order of oldest to newest
+	SortTitle  = "title"  // return items in order of title alphabetically
+	SortSite   = "site"   // return items in order of url alphabetically
+)
+
+// values for WithDetailType
+const (
+	DetailTypeSimple   = "simple"   // return basic information about each item
+	DetailTypeComplete = "complete" // return all data about each item, including tags, images, authors, videos
+)
+
 // ArticleGetResponse ...
 type ArticleGetResponse struct {
-	Status int                 `json:"status"`
-	List   *map[string]Article `json:"list"`
+	Status   int                 `json:"status"`
+	Complete int                 `json:"complete"`
+	Since    int64               `json:"since"` // server time of request; use it as since of next delta request
+	List     *map[string]Article `json:"list"`  // nil if there is no items
 }
 
 // Get Retrieving a User's Pocket Data
-func (a *ArticlesAPI) Get(opts ...GetOption) (map[string]Article, error) {
-	params := map[string]interface{}{
-		"consumer_key": a.pocket.consumerKey,
-		"access_token": a.pocket.accessToken,
-		"state":        "all",
-		"detailType":   "simple",
+func (a *ArticlesAPI) Get(ctx context.Context, opts ...GetOption) (map[string]Article, error) {
+	response, err := a.get(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.List == nil {
+		return nil, nil
+	}
+
+	return *response.List, nil
+}
+
+// get retrieve articles with whole response
+func (a *ArticlesAPI) get(ctx context.Context, opts ...GetOption) (*ArticleGetResponse, error) {
+	if a.pocket.accessToken == "" {
+		return nil, ErrMissingAccessToken
 	}
 
-	var getOptions GetOptions
+	getOptions := GetOptions{
+		state:      StateAll,
+		detailType: DetailTypeSimple,
+	}
 	for _, o := range opts {
 		o.apply(&getOptions)
 	}
 
+	params := map[string]interface{}{
+		"consumer_key": a.pocket.consumerKey,
+		"access_token": a.pocket.accessToken,
+		"state":        getOptions.state,
+		"detailType":   getOptions.detailType,
+	}
+
 	if getOptions.favorite != 0 {
 		params["favorite"] = strconv.FormatInt(int64(getOptions.favorite-1), 10)
 	}
@@ -186,9 +280,30 @@ func (a *ArticlesAPI) Get(opts ...GetOption) (map[string]Article, error) {
 		params["domain"] = getOptions.domain
 	}
 
-	resp, err := a.pocket.sess.Post("https://getpocket.com/v3/get").
-		Header("X-"+echo.HeaderAccept, echo.MIMEApplicationJSON).
-		JSON(params).Do()
+	if getOptions.tag != "" {
+		params["tag"] = getOptions.tag
+	}
+
+	if getOptions.contentType != "" {
+		params["contentType"] = getOptions.contentType
+	}
+
+	if getOptions.sort != "" {
+		params["sort"] = getOptions.sort
+	}
+
+	if !getOptions.since.IsZero() {
+		params["since"] = getOptions.since.Unix()
+	}
+
+	if getOptions.count > 0 {
+		params["count"] = getOptions.count
+		if getOptions.offset > 0 {
+			params["offset"] = getOptions.offset
+		}
+	}
+
+	resp, err := a.pocket.do(ctx, a.pocket.post(ctx, "/get").JSON(params))
 	if err != nil {
 		return nil, err
 	}
@@ -207,42 +322,91 @@ func (a *ArticlesAPI) Get(opts ...GetOption) (map[string]Article, error) {
 
 	// return empty list if there is no items searched
 	var emptyResponse struct {
-		List []string `json:"list"`
+		Status   int      `json:"status"`
+		Complete int      `json:"complete"`
+		Since    int64    `json:"since"`
+		List     []string `json:"list"`
 	}
 	if err := json.NewDecoder(tee).Decode(&emptyResponse); err == nil {
-		return nil, err
+		return &ArticleGetResponse{
+			Status:   emptyResponse.Status,
+			Complete: emptyResponse.Complete,
+			Since:    emptyResponse.Since,
+		}, nil
 	}
 
 	var response ArticleGetResponse
 	if err := json.NewDecoder(&buf1).Decode(&response); err != nil {
-		errors.Wrap(err, "JSONDecode")
-		return nil, err
+		return nil, errors.Wrap(err, "JSONDecode")
 	}
 
-	return *response.List, nil
+	return &response, nil
 }
 
+// action names of Modify API
+const (
+	ActionAdd         = "add"
+	ActionArchive     = "archive"
+	ActionReadd       = "readd"
+	ActionFavorite    = "favorite"
+	ActionUnfavorite  = "unfavorite"
+	ActionDelete      = "delete"
+	ActionTagsAdd     = "tags_add"
+	ActionTagsRemove  = "tags_remove"
+	ActionTagsReplace = "tags_replace"
+	ActionTagsClear   = "tags_clear"
+	ActionTagRename   = "tag_rename"
+	ActionTagDelete   = "tag_delete"
+)
+
+// articleActionParam action of Modify API, see https://getpocket.com/developer/docs/v3/modify
 type articleActionParam struct {
 	Action string `json:"action"`
-	ItemID string `json:"item_id"`
+	ItemID string `json:"item_id,omitempty"`
 	Time   string `json:"time,omitempty"`
+	Tags   string `json:"tags,omitempty"`    // comma-separated list of tags
+	OldTag string `json:"old_tag,omitempty"` // for tag_rename
+	NewTag string `json:"new_tag,omitempty"` // for tag_rename
+	Tag    string `json:"tag,omitempty"`     // for tag_delete
+	URL    string `json:"url,omitempty"`     // for add
+	Title  string `json:"title,omitempty"`   // for add
+	RefID  string `json:"ref_id,omitempty"`  // tweet id for add
 }
 
 type articleActionResults struct {
-	ActionResults []bool `json:"action_results"`
-	Status        int    `json:"status"`
+	// result of each action; false if failed, true or item object of added item if success
+	ActionResults []json.RawMessage `json:"action_results"`
+	Status        int               `json:"status"`
+}
+
+// succeeded return true if action of index i was succeeded
+func (r *articleActionResults) succeeded(i int) bool {
+	if i >= len(r.ActionResults) {
+		return false
+	}
+
+	switch string(r.ActionResults[i]) {
+	case "false", "null", "":
+		return false
+	}
+
+	return true
 }
 
-func (a *ArticlesAPI) sendAction(actions []articleActionParam) (*articleActionResults, error) {
+// send post actions and return results without checking each result
+func (a *ArticlesAPI) send(ctx context.Context, actions []articleActionParam) (*articleActionResults, error) {
+	if a.pocket.accessToken == "" {
+		return nil, ErrMissingAccessToken
+	}
+
 	var buf bytes.Buffer
 	json.NewEncoder(&buf).Encode(&actions)
 
 	log.Debugf("actions: %+v", actions)
-	resp, err := a.pocket.sess.Post("https://getpocket.com/v3/send").
+	resp, err := a.pocket.do(ctx, a.pocket.post(ctx, "/send").
 		Form("consumer_key", a.pocket.consumerKey).
 		Form("access_token", a.pocket.accessToken).
-		Form("actions", buf.String()).
-		Do()
+		Form("actions", buf.String()))
 	if err != nil {
 		return nil, err
 	}
@@ -257,28 +421,180 @@ func (a *ArticlesAPI) sendAction(actions []articleActionParam) (*articleActionRe
 	}
 	log.Debugf("resp: %+v", response)
 
-	if !response.ActionResults[0] {
-		return nil, fmt.Errorf("delete failed: %v, %d", response.ActionResults[0], response.Status)
+	return &response, nil
+}
+
+// sendAction post actions and return error if any of actions failed
+func (a *ArticlesAPI) sendAction(ctx context.Context, actions []articleActionParam) (*articleActionResults, error) {
+	response, err := a.send(ctx, actions)
+	if err != nil {
+		return nil, err
 	}
 
-	return &response, nil
+	for i := range actions {
+		if !response.succeeded(i) {
+			return nil, fmt.Errorf("%s failed: item=%s, status=%d: %w", actions[i].Action, actions[i].ItemID, response.Status, ErrActionFailed)
+		}
+	}
+
+	return response, nil
+}
+
+// modify send same action to all items
+func (a *ArticlesAPI) modify(ctx context.Context, action string, itemIDs []string) error {
+	log.Debugf("%s item: %s", action, itemIDs)
+
+	if _, err := a.Batch().add(action, itemIDs...).Send(ctx); err != nil {
+		return errors.Wrapf(err, "%s(%s)", action, itemIDs)
+	}
+
+	return nil
 }
 
 // Delete delete article by item id
 // NOTE Delete action always success ㅡㅡ;
-func (a *ArticlesAPI) Delete(itemIDs ...string) error {
-	log.Debugf("remove item: %s", itemIDs)
+func (a *ArticlesAPI) Delete(ctx context.Context, itemIDs ...string) error {
+	return a.modify(ctx, ActionDelete, itemIDs)
+}
+
+// Archive move articles to archive
+func (a *ArticlesAPI) Archive(ctx context.Context, itemIDs ...string) error {
+	return a.modify(ctx, ActionArchive, itemIDs)
+}
+
+// Readd move articles from archive back to unread list
+func (a *ArticlesAPI) Readd(ctx context.Context, itemIDs ...string) error {
+	return a.modify(ctx, ActionReadd, itemIDs)
+}
+
+// Favorite mark articles as favorite
+func (a *ArticlesAPI) Favorite(ctx context.Context, itemIDs ...string) error {
+	return a.modify(ctx, ActionFavorite, itemIDs)
+}
+
+// Unfavorite remove articles from favorite
+func (a *ArticlesAPI) Unfavorite(ctx context.Context, itemIDs ...string) error {
+	return a.modify(ctx, ActionUnfavorite, itemIDs)
+}
+
+// TagsClear remove all tags from articles
+func (a *ArticlesAPI) TagsClear(ctx context.Context, itemIDs ...string) error {
+	return a.modify(ctx, ActionTagsClear, itemIDs)
+}
+
+// modifyTags send tags action to an article
+func (a *ArticlesAPI) modifyTags(ctx context.Context, action string, itemID string, tags []string) error {
+	log.Debugf("%s item: %s, tags: %s", action, itemID, tags)
+
+	if _, err := a.Batch().addTags(action, itemID, tags).Send(ctx); err != nil {
+		return errors.Wrapf(err, "%s(%s, %s)", action, itemID, tags)
+	}
+
+	return nil
+}
+
+// TagsAdd add tags to article
+func (a *ArticlesAPI) TagsAdd(ctx context.Context, itemID string, tags ...string) error {
+	return a.modifyTags(ctx, ActionTagsAdd, itemID, tags)
+}
+
+// TagsRemove remove tags from article
+func (a *ArticlesAPI) TagsRemove(ctx context.Context, itemID string, tags ...string) error {
+	return a.modifyTags(ctx, ActionTagsRemove, itemID, tags)
+}
 
-	params := make([]articleActionParam, len(itemIDs))
-	for i := 0; i < len(itemIDs); i++ {
-		params[i].Action = "delete"
-		params[i].ItemID = itemIDs[i]
+// TagsReplace replace all tags of article with given tags
+func (a *ArticlesAPI) TagsReplace(ctx context.Context, itemID string, tags ...string) error {
+	return a.modifyTags(ctx, ActionTagsReplace, itemID, tags)
+}
+
+// TagRename rename tag; this affects all articles with the tag
+func (a *ArticlesAPI) TagRename(ctx context.Context, oldTag, newTag string) error {
+	_, err := a.sendAction(ctx, []articleActionParam{{
+		Action: ActionTagRename,
+		OldTag: oldTag,
+		NewTag: newTag,
+	}})
+	if err != nil {
+		return errors.Wrapf(err, "tagRename(%s, %s)", oldTag, newTag)
 	}
 
-	_, err := a.sendAction(params)
+	return nil
+}
+
+// TagDelete delete tag; this affects all articles with the tag
+func (a *ArticlesAPI) TagDelete(ctx context.Context, tag string) error {
+	_, err := a.sendAction(ctx, []articleActionParam{{
+		Action: ActionTagDelete,
+		Tag:    tag,
+	}})
 	if err != nil {
-		return errors.Wrapf(err, "delete(%s)", itemIDs)
+		return errors.Wrapf(err, "tagDelete(%s)", tag)
 	}
 
 	return nil
 }
+
+// AddAction add new article with add action of Modify API
+// tweetID is optional, to associate the article with a tweet
+// returned article is nil if pocket does not return the added item
+func (a *ArticlesAPI) AddAction(ctx context.Context, url, title string, tags []string, tweetID string) (*Article, error) {
+	response, err := a.sendAction(ctx, []articleActionParam{{
+		Action: ActionAdd,
+		URL:    url,
+		Title:  title,
+		Tags:   strings.Join(tags, ","),
+		RefID:  tweetID,
+	}})
+	if err != nil {
+		return nil, errors.Wrapf(err, "add(%s)", url)
+	}
+
+	var article Article
+	if err := json.Unmarshal(response.ActionResults[0], &article); err != nil || article.ItemID == "" {
+		// pocket may return just true for add action
+		return nil, nil
+	}
+
+	return &article, nil
+}
+
+// Add save new article to pocket, see https://getpocket.com/developer/docs/v3/add
+func (a *ArticlesAPI) Add(ctx context.Context, url, title string, tags ...string) (*Article, error) {
+	log.Debugf("add article: %s", url)
+
+	if a.pocket.accessToken == "" {
+		return nil, ErrMissingAccessToken
+	}
+
+	params := map[string]string{
+		"consumer_key": a.pocket.consumerKey,
+		"access_token": a.pocket.accessToken,
+		"url":          url,
+	}
+	if title != "" {
+		params["title"] = title
+	}
+	if len(tags) > 0 {
+		params["tags"] = strings.Join(tags, ",")
+	}
+
+	resp, err := a.pocket.do(ctx, a.pocket.post(ctx, "/add").JSON(params))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.pocket.success(resp); err != nil {
+		return nil, errors.Wrapf(err, "add(%s)", url)
+	}
+
+	var response struct {
+		Item   Article `json:"item"`
+		Status int     `json:"status"`
+	}
+	if err := resp.JSON(&response); err != nil {
+		return nil, errors.Wrapf(err, "decode response")
+	}
+
+	return &response.Item, nil
+}
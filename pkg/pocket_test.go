@@ -1,23 +1,87 @@
 package pocket
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
-	"github.com/whitekid/pocket-pick/pkg/config"
+	"github.com/whitekid/go-utils/request"
+	"github.com/whitekid/pocket-pick/pkg/pockettest"
 )
 
+// newTestAPI create api which talks with fake pocket server
+func newTestAPI(pocketServer *pockettest.Server, accessToken string, opts ...APIOption) *GetPocketAPI {
+	return NewGetPocketAPI(pockettest.ConsumerKey, accessToken, append(testAPIOptions(pocketServer), opts...)...)
+}
+
+// testAPIOptions options for fake pocket server with short retry backoff
+func testAPIOptions(pocketServer *pockettest.Server) []APIOption {
+	return []APIOption{
+		WithAPIURL(pocketServer.APIURL()),
+		WithAuthorizeURL(pocketServer.AuthorizeURL()),
+		WithRetry(3, time.Millisecond*10),
+		WithMaxRetryWait(time.Millisecond * 100),
+	}
+}
+
 func TestGetAuthorizedURL(t *testing.T) {
-	api := NewGetPocketAPI(config.ConsumerKey(), "")
+	pocketServer := pockettest.NewServer()
+	defer pocketServer.Close()
+
+	api := newTestAPI(pocketServer, "")
 
-	token, url, err := api.AuthorizedURL("http://127.0.0.1")
+	token, url, err := api.AuthorizedURL(context.Background(), "http://127.0.0.1")
 	require.NoError(t, err, "error = %v", err)
 	require.NotEqual(t, "", token)
 	require.NotEqual(t, "", url)
 }
 
+func TestAPIOptions(t *testing.T) {
+	var gotPath, gotUserAgent string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotUserAgent = r.Header.Get("User-Agent")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"code":"request-token"}`))
+	}))
+	defer ts.Close()
+
+	api := NewGetPocketAPI("consumer-key", "",
+		WithAPIURL(ts.URL+"/v3/"),
+		WithAuthorizeURL("http://127.0.0.1/authorize"),
+		WithHTTPClient(ts.Client()),
+		WithUserAgent("pocket-pick-test"))
+
+	token, url, err := api.AuthorizedURL(context.Background(), "http://127.0.0.1/auth")
+	require.NoError(t, err)
+	require.Equal(t, "/v3/oauth/request", gotPath)
+	require.Equal(t, "pocket-pick-test", gotUserAgent)
+	require.Equal(t, "request-token", token)
+	require.True(t, strings.HasPrefix(url, "http://127.0.0.1/authorize?request_token=request-token"), url)
+}
+
 func TestAuthorize(t *testing.T) {
-	// need to mock web site
+	pocketServer := pockettest.NewServer()
+	defer pocketServer.Close()
+
+	api := newTestAPI(pocketServer, "")
+	token, authorizeURL, err := api.AuthorizedURL(context.Background(), "http://127.0.0.1/auth")
+	require.NoError(t, err)
+
+	// user approve the request token
+	resp, err := request.Get(authorizeURL).FollowRedirect(false).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	accessToken, username, err := api.NewAccessToken(context.Background(), token)
+	require.NoError(t, err)
+	require.NotEqual(t, "", accessToken)
+	require.Equal(t, pockettest.Username, username)
 }
 
 func TestArticleSearch(t *testing.T) {
@@ -34,8 +98,15 @@ func TestArticleSearch(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			api := NewGetPocketAPI(config.ConsumerKey(), config.AccessToken())
-			items, err := api.Articles.Get(WithSearch(tt.args.url))
+			pocketServer := pockettest.NewServer()
+			defer pocketServer.Close()
+			pocketServer.AddItems(
+				pockettest.Item{GivenURL: tt.args.url},
+				pockettest.Item{GivenURL: "https://blog.golang.org/"},
+			)
+
+			api := newTestAPI(pocketServer, pockettest.AccessToken)
+			items, err := api.Articles.Get(context.Background(), WithSearch(tt.args.url))
 			require.NoError(t, err)
 			require.Equal(t, 1, len(items))
 
@@ -48,6 +119,191 @@ func TestArticleSearch(t *testing.T) {
 }
 
 func TestArticleDelete(t *testing.T) {
-	api := NewGetPocketAPI(config.ConsumerKey(), config.AccessToken())
-	require.NoError(t, api.Articles.Delete("567640688"))
+	pocketServer := pockettest.NewServer()
+	defer pocketServer.Close()
+	ids := pocketServer.AddItems(pockettest.Item{GivenURL: "https://blog.golang.org/"})
+
+	api := newTestAPI(pocketServer, pockettest.AccessToken)
+	require.NoError(t, api.Articles.Delete(context.Background(), ids[0]))
+	require.Equal(t, 0, len(pocketServer.Items()))
+	require.Equal(t, []pockettest.Action{{Action: "delete", ItemID: ids[0]}}, pocketServer.Actions())
+}
+
+func TestArticleGetOptions(t *testing.T) {
+	pocketServer := pockettest.NewServer()
+	defer pocketServer.Close()
+
+	pocketServer.AddItems(
+		pockettest.Item{ItemID: "1", GivenURL: "https://golang.org/", TimeAdded: "100", Tags: map[string]pockettest.Tag{"go": {}}},
+		pockettest.Item{ItemID: "2", GivenURL: "https://rust-lang.org/", TimeAdded: "200", Status: "1"},
+		pockettest.Item{ItemID: "3", GivenURL: "https://youtube.com/", TimeAdded: "300", HasVideo: "1"},
+	)
+
+	type args struct {
+		opts []GetOption
+	}
+	tests := [...]struct {
+		name    string
+		args    args
+		wantIDs []string
+	}{
+		{"default", args{nil}, []string{"1", "2", "3"}},
+		{"unread", args{[]GetOption{WithState(StateUnread)}}, []string{"1", "3"}},
+		{"archive", args{[]GetOption{WithState(StateArchive)}}, []string{"2"}},
+		{"tag", args{[]GetOption{WithTag("go")}}, []string{"1"}},
+		{"untagged", args{[]GetOption{WithTag(TagUntagged)}}, []string{"2", "3"}},
+		{"video", args{[]GetOption{WithContentType(ContentTypeVideo)}}, []string{"3"}},
+		{"count", args{[]GetOption{WithSort(SortOldest), WithCount(2)}}, []string{"1", "2"}},
+		{"offset", args{[]GetOption{WithSort(SortOldest), WithCount(2), WithOffset(2)}}, []string{"3"}},
+		{"since", args{[]GetOption{WithSince(time.Now().Add(time.Hour))}}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(pocketServer, pockettest.AccessToken)
+			items, err := api.Articles.Get(context.Background(), tt.args.opts...)
+			require.NoError(t, err)
+
+			ids := make([]string, 0, len(items))
+			for id := range items {
+				ids = append(ids, id)
+			}
+			require.ElementsMatch(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func TestArticleModify(t *testing.T) {
+	type args struct {
+		modify func(api *ArticlesAPI, itemID string) error
+	}
+	tests := [...]struct {
+		name     string
+		args     args
+		wantItem func(t *testing.T, item pockettest.Item)
+	}{
+		{"archive", args{func(api *ArticlesAPI, itemID string) error { return api.Archive(context.Background(), itemID) }},
+			func(t *testing.T, item pockettest.Item) { require.Equal(t, "1", item.Status) }},
+		{"readd", args{func(api *ArticlesAPI, itemID string) error { return api.Readd(context.Background(), itemID) }},
+			func(t *testing.T, item pockettest.Item) { require.Equal(t, "0", item.Status) }},
+		{"favorite", args{func(api *ArticlesAPI, itemID string) error { return api.Favorite(context.Background(), itemID) }},
+			func(t *testing.T, item pockettest.Item) { require.Equal(t, "1", item.Favorite) }},
+		{"unfavorite", args{func(api *ArticlesAPI, itemID string) error { return api.Unfavorite(context.Background(), itemID) }},
+			func(t *testing.T, item pockettest.Item) { require.Equal(t, "0", item.Favorite) }},
+		{"tags_add", args{func(api *ArticlesAPI, itemID string) error {
+			return api.TagsAdd(context.Background(), itemID, "rust", "web")
+		}},
+			func(t *testing.T, item pockettest.Item) { require.Equal(t, 3, len(item.Tags)) }},
+		{"tags_remove", args{func(api *ArticlesAPI, itemID string) error { return api.TagsRemove(context.Background(), itemID, "go") }},
+			func(t *testing.T, item pockettest.Item) { require.Equal(t, 0, len(item.Tags)) }},
+		{"tags_replace", args{func(api *ArticlesAPI, itemID string) error {
+			return api.TagsReplace(context.Background(), itemID, "web")
+		}},
+			func(t *testing.T, item pockettest.Item) {
+				require.Equal(t, map[string]pockettest.Tag{"web": {ItemID: item.ItemID, Tag: "web"}}, item.Tags)
+			}},
+		{"tags_clear", args{func(api *ArticlesAPI, itemID string) error { return api.TagsClear(context.Background(), itemID) }},
+			func(t *testing.T, item pockettest.Item) { require.Equal(t, 0, len(item.Tags)) }},
+		{"tag_rename", args{func(api *ArticlesAPI, itemID string) error {
+			return api.TagRename(context.Background(), "go", "golang")
+		}},
+			func(t *testing.T, item pockettest.Item) {
+				require.Equal(t, map[string]pockettest.Tag{"golang": {ItemID: item.ItemID, Tag: "golang"}}, item.Tags)
+			}},
+		{"tag_delete", args{func(api *ArticlesAPI, itemID string) error { return api.TagDelete(context.Background(), "go") }},
+			func(t *testing.T, item pockettest.Item) { require.Equal(t, 0, len(item.Tags)) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pocketServer := pockettest.NewServer()
+			defer pocketServer.Close()
+			ids := pocketServer.AddItems(pockettest.Item{
+				GivenURL: "https://golang.org/",
+				Favorite: "1",
+				Status:   "1",
+				Tags:     map[string]pockettest.Tag{"go": {}},
+			})
+
+			api := newTestAPI(pocketServer, pockettest.AccessToken)
+			require.NoError(t, tt.args.modify(api.Articles, ids[0]))
+
+			actions := pocketServer.Actions()
+			require.Equal(t, 1, len(actions))
+			require.Equal(t, tt.name, actions[0].Action)
+
+			item, ok := pocketServer.Item(ids[0])
+			require.True(t, ok)
+			tt.wantItem(t, item)
+		})
+	}
+}
+
+func TestArticleModifyFailed(t *testing.T) {
+	pocketServer := pockettest.NewServer()
+	defer pocketServer.Close()
+
+	api := newTestAPI(pocketServer, pockettest.AccessToken)
+	err := api.Articles.Archive(context.Background(), "not-found")
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrActionFailed))
+}
+
+func TestArticleAddAction(t *testing.T) {
+	pocketServer := pockettest.NewServer()
+	defer pocketServer.Close()
+
+	api := newTestAPI(pocketServer, pockettest.AccessToken)
+	article, err := api.Articles.AddAction(context.Background(), "https://golang.org/", "The Go Programming Language", []string{"go", "lang"}, "1234")
+	require.NoError(t, err)
+	require.NotNil(t, article)
+	require.Equal(t, "https://golang.org/", article.GivenURL)
+
+	require.Equal(t, []pockettest.Action{{
+		Action: "add",
+		URL:    "https://golang.org/",
+		Title:  "The Go Programming Language",
+		Tags:   "go,lang",
+		RefID:  "1234",
+	}}, pocketServer.Actions())
+
+	item, ok := pocketServer.Item(article.ItemID)
+	require.True(t, ok)
+	require.Equal(t, 2, len(item.Tags))
+}
+
+func TestArticleAdd(t *testing.T) {
+	pocketServer := pockettest.NewServer()
+	defer pocketServer.Close()
+
+	api := newTestAPI(pocketServer, pockettest.AccessToken)
+	article, err := api.Articles.Add(context.Background(), "https://golang.org/", "The Go Programming Language", "go", "lang")
+	require.NoError(t, err)
+	require.NotEqual(t, "", article.ItemID)
+	require.Equal(t, "https://golang.org/", article.GivenURL)
+
+	item, ok := pocketServer.Item(article.ItemID)
+	require.True(t, ok)
+	require.Equal(t, "The Go Programming Language", item.GivenTitle)
+	require.Equal(t, 2, len(item.Tags))
+
+	// pocket returns [] for empty tags
+	article, err = api.Articles.Add(context.Background(), "https://go.dev/", "")
+	require.NoError(t, err)
+	require.NotEqual(t, "", article.ItemID)
+	require.Empty(t, article.Tags)
+}
+
+func TestContextCancel(t *testing.T) {
+	pocketServer := pockettest.NewServer()
+	defer pocketServer.Close()
+	pocketServer.InjectFault(pockettest.Fault{Path: "/v3/get", Delay: 300 * time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	api := newTestAPI(pocketServer, pockettest.AccessToken)
+	start := time.Now()
+	_, err := api.Articles.Get(ctx)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, context.DeadlineExceeded), "%+v", err)
+	require.Less(t, int64(time.Since(start)), int64(300*time.Millisecond))
 }
@@ -0,0 +1,974 @@
+// Package pockettest provides an in-process fake of getpocket.com v3 API for tests
+//
+// Server implements the oauth flow, /v3/get, /v3/send and /v3/add with in-memory items,
+// and supports fault injection such as rate limit errors and slow responses.
+package pockettest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default credentials that Server accepts
+const (
+	ConsumerKey = "test-consumer-key"
+	AccessToken = "test-access-token"
+	Username    = "pockettest"
+)
+
+// Item pocket item as it was sent by /v3/get, numbers are encoded as string like pocket does
+type Item struct {
+	ItemID         string            `json:"item_id"`
+	ResolvedID     string            `json:"resolved_id"`
+	GivenURL       string            `json:"given_url"`
+	GivenTitle     string            `json:"given_title"`
+	Favorite       string            `json:"favorite"`
+	Status         string            `json:"status"`
+	TimeAdded      string            `json:"time_added"`
+	TimeUpdated    string            `json:"time_updated"`
+	TimeRead       string            `json:"time_read"`
+	TimeFavorited  string            `json:"time_favorited"`
+	SortID         int               `json:"sort_id"`
+	ResolvedTitle  string            `json:"resolved_title"`
+	ResolvedURL    string            `json:"resolved_url"`
+	Excerpt        string            `json:"excerpt"`
+	IsArticle      string            `json:"is_article"`
+	IsIndex        string            `json:"is_index"`
+	HasVideo       string            `json:"has_video"`
+	HasImage       string            `json:"has_image"`
+	WordCount      string            `json:"word_count"`
+	Lang           string            `json:"lang"`
+	TimeToRead     int               `json:"time_to_read,omitempty"`
+	TopImageURL    string            `json:"top_image_url,omitempty"`
+	Tags           map[string]Tag    `json:"tags,omitempty"`
+	Authors        map[string]Author `json:"authors,omitempty"`
+	DomainMetadata *DomainMetadata   `json:"domain_metadata,omitempty"`
+}
+
+// Tag tag of item
+type Tag struct {
+	ItemID string `json:"item_id"`
+	Tag    string `json:"tag"`
+}
+
+// Author author of item
+type Author struct {
+	ItemID   string `json:"item_id"`
+	AuthorID string `json:"author_id"`
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+}
+
+// DomainMetadata domain informations of item
+type DomainMetadata struct {
+	Name          string `json:"name"`
+	Logo          string `json:"logo"`
+	GreyscaleLogo string `json:"greyscale_logo"`
+}
+
+// Action action received by /v3/send or /v3/add
+type Action struct {
+	Action string `json:"action"`
+	ItemID string `json:"item_id,omitempty"`
+	Time   string `json:"time,omitempty"`
+	Tags   string `json:"tags,omitempty"`
+	OldTag string `json:"old_tag,omitempty"`
+	NewTag string `json:"new_tag,omitempty"`
+	Tag    string `json:"tag,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Title  string `json:"title,omitempty"`
+	RefID  string `json:"ref_id,omitempty"`
+}
+
+// Fault fault to inject to responses
+type Fault struct {
+	Path       string        // request path to match such as "/v3/get", empty matches all
+	StatusCode int           // response status, 0 to serve normally after Delay
+	ErrorCode  int           // value of X-Error-Code header
+	Error      string        // value of X-Error header
+	Header     http.Header   // additional response headers such as Retry-After
+	Delay      time.Duration // delay before response
+	Times      int           // apply fault n times, 0 means forever
+}
+
+// RateLimit quota of requests per window; zero limit means unlimited
+type RateLimit struct {
+	UserLimit int           // requests per access token
+	KeyLimit  int           // requests per consumer key
+	Window    time.Duration // quota is reset after window, default is an hour
+}
+
+type quota struct {
+	used  int
+	reset time.Time
+}
+
+type pendingAuth struct {
+	redirectURI string
+	approved    bool
+	rejected    bool
+	used        bool
+}
+
+// Server fake pocket server
+type Server struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	items        map[string]*Item
+	nextID       int
+	requestToken int
+	auths        map[string]*pendingAuth // request token -> auth status
+	accessTokens map[string]string       // access token -> username
+	issued       []string                // access tokens issued by oauth, in order
+	reject       bool                    // reject user authorization
+	rateLimit    RateLimit
+	quotas       map[string]*quota // "user/<token>" or "key/<consumer key>" -> quota
+	faults       []*Fault
+	actions      []Action
+	requests     map[string]int // path -> request count
+	maxCount     int            // max items of a /v3/get response, 0 if unlimited
+}
+
+// NewServer start new fake pocket server; caller should Close() it
+func NewServer() *Server {
+	s := &Server{
+		items:        make(map[string]*Item),
+		nextID:       1000,
+		auths:        make(map[string]*pendingAuth),
+		accessTokens: map[string]string{AccessToken: Username},
+		requests:     make(map[string]int),
+		quotas:       make(map[string]*quota),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/oauth/request", s.handleOAuthRequest)
+	mux.HandleFunc("/v3/oauth/authorize", s.handleOAuthAuthorize)
+	mux.HandleFunc("/v3/get", s.handleGet)
+	mux.HandleFunc("/v3/send", s.handleSend)
+	mux.HandleFunc("/v3/add", s.handleAdd)
+	mux.HandleFunc("/auth/authorize", s.handleUserAuthorize)
+
+	s.Server = httptest.NewServer(s.middleware(mux))
+	return s
+}
+
+// APIURL base url of v3 api
+func (s *Server) APIURL() string { return s.URL + "/v3" }
+
+// AuthorizeURL url where user authorize the request token
+func (s *Server) AuthorizeURL() string { return s.URL + "/auth/authorize" }
+
+// AddItems seed items; missing fields are filled with defaults and assigned item ids are returned
+func (s *Server) AddItems(items ...Item) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, len(items))
+	for i := range items {
+		item := items[i]
+		s.fillItem(&item)
+		s.items[item.ItemID] = &item
+		ids[i] = item.ItemID
+	}
+
+	return ids
+}
+
+func (s *Server) fillItem(item *Item) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+
+	if item.ItemID == "" {
+		s.nextID++
+		item.ItemID = strconv.Itoa(s.nextID)
+	}
+	if item.ResolvedID == "" {
+		item.ResolvedID = item.ItemID
+	}
+	if item.ResolvedURL == "" {
+		item.ResolvedURL = item.GivenURL
+	}
+	if item.GivenURL == "" {
+		item.GivenURL = item.ResolvedURL
+	}
+	if item.ResolvedTitle == "" {
+		item.ResolvedTitle = item.GivenTitle
+	}
+	for _, v := range []*string{&item.Favorite, &item.Status, &item.TimeRead, &item.TimeFavorited,
+		&item.IsArticle, &item.IsIndex, &item.HasVideo, &item.HasImage, &item.WordCount} {
+		if *v == "" {
+			*v = "0"
+		}
+	}
+	if item.TimeAdded == "" {
+		item.TimeAdded = now
+	}
+	if item.TimeUpdated == "" {
+		item.TimeUpdated = now
+	}
+	for k, tag := range item.Tags {
+		tag.ItemID = item.ItemID
+		tag.Tag = k
+		item.Tags[k] = tag
+	}
+}
+
+// clone deep copy item so that it can be used without lock
+func (item *Item) clone() Item {
+	v := *item
+	if item.Tags != nil {
+		v.Tags = make(map[string]Tag, len(item.Tags))
+		for k, tag := range item.Tags {
+			v.Tags[k] = tag
+		}
+	}
+
+	return v
+}
+
+// Item return item by id, deleted items are also returned with status 2
+func (s *Server) Item(itemID string) (Item, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[itemID]
+	if !ok {
+		return Item{}, false
+	}
+
+	return item.clone(), true
+}
+
+// Items return all items that are not deleted
+func (s *Server) Items() []Item {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := make([]Item, 0, len(s.items))
+	for _, item := range s.items {
+		if item.Status != "2" {
+			items = append(items, item.clone())
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ItemID < items[j].ItemID })
+
+	return items
+}
+
+// Actions return actions received by /v3/send and /v3/add in order
+func (s *Server) Actions() []Action {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Action(nil), s.actions...)
+}
+
+// RequestCount return number of requests for given path such as "/v3/get"
+func (s *Server) RequestCount(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[path]
+}
+
+// IssuedAccessTokens return access tokens issued by oauth flow in order
+func (s *Server) IssuedAccessTokens() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.issued...)
+}
+
+// InjectFault add fault to responses; faults are matched in order they were injected
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults remove all injected faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+}
+
+// SetRateLimit set quota of requests; X-Limit-* headers are sent and requests over quota are rejected with 403
+func (s *Server) SetRateLimit(limit RateLimit) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if limit.Window == 0 {
+		limit.Window = time.Hour
+	}
+	s.rateLimit = limit
+	s.quotas = make(map[string]*quota)
+}
+
+// SetMaxCount limit items of a /v3/get response regardless of count, as pocket may return fewer items than requested
+func (s *Server) SetMaxCount(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maxCount = n
+}
+
+// RejectAuthorization make user reject(or approve) authorization at AuthorizeURL
+func (s *Server) RejectAuthorization(reject bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reject = reject
+}
+
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		fault := s.matchFault(r.URL.Path)
+		s.mu.Unlock()
+
+		if fault != nil {
+			if fault.Delay > 0 {
+				select {
+				case <-time.After(fault.Delay):
+				case <-r.Context().Done():
+					return
+				}
+			}
+
+			if fault.StatusCode != 0 {
+				for k, v := range fault.Header {
+					w.Header()[k] = v
+				}
+				writeError(w, fault.StatusCode, fault.ErrorCode, fault.Error)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// matchFault return fault for path and decrease remaining times; caller should hold lock
+func (s *Server) matchFault(path string) *Fault {
+	for i, f := range s.faults {
+		if f.Path != "" && f.Path != path {
+			continue
+		}
+
+		matched := *f
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+
+		return &matched
+	}
+
+	return nil
+}
+
+func writeError(w http.ResponseWriter, status int, code int, message string) {
+	if message != "" {
+		w.Header().Set("X-Error", message)
+	}
+	if code != 0 {
+		w.Header().Set("X-Error-Code", strconv.Itoa(code))
+	}
+	w.WriteHeader(status)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// readParams read request parameters from json body or form
+func readParams(r *http.Request) (map[string]interface{}, error) {
+	params := make(map[string]interface{})
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		if len(body) == 0 {
+			return params, nil
+		}
+		if err := json.Unmarshal(body, &params); err != nil {
+			return nil, err
+		}
+		return params, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	for k := range r.Form {
+		params[k] = r.Form.Get(k)
+	}
+
+	return params, nil
+}
+
+func param(params map[string]interface{}, key string) string {
+	switch v := params[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// authenticate check consumer key and access token; write error response and return false if failed
+func (s *Server) authenticate(w http.ResponseWriter, params map[string]interface{}, requireAccessToken bool) bool {
+	consumerKey := param(params, "consumer_key")
+	if consumerKey == "" {
+		writeError(w, http.StatusBadRequest, 138, "Missing consumer key.")
+		return false
+	}
+	if consumerKey != ConsumerKey {
+		writeError(w, http.StatusForbidden, 152, "Invalid consumer key.")
+		return false
+	}
+
+	if !requireAccessToken {
+		return true
+	}
+
+	accessToken := param(params, "access_token")
+	if accessToken == "" {
+		writeError(w, http.StatusUnauthorized, 107, "Missing access token.")
+		return false
+	}
+
+	s.mu.Lock()
+	_, ok := s.accessTokens[accessToken]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusUnauthorized, 107, "Consumer key/access token mismatch.")
+		return false
+	}
+
+	return s.consumeQuota(w, consumerKey, accessToken)
+}
+
+// consumeQuota count request to quota and write X-Limit-* headers; write error response and return false if over quota
+func (s *Server) consumeQuota(w http.ResponseWriter, consumerKey, accessToken string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	exceeded := false
+	var retryAfter time.Duration
+
+	for _, l := range []struct {
+		name  string
+		key   string
+		limit int
+	}{
+		{"User", "user/" + accessToken, s.rateLimit.UserLimit},
+		{"Key", "key/" + consumerKey, s.rateLimit.KeyLimit},
+	} {
+		if l.limit == 0 {
+			continue
+		}
+
+		q, ok := s.quotas[l.key]
+		if !ok || !now.Before(q.reset) {
+			q = &quota{reset: now.Add(s.rateLimit.Window)}
+			s.quotas[l.key] = q
+		}
+
+		if q.used >= l.limit {
+			exceeded = true
+			if d := q.reset.Sub(now); d > retryAfter {
+				retryAfter = d
+			}
+		} else {
+			q.used++
+		}
+
+		reset := int(q.reset.Sub(now).Round(time.Second) / time.Second)
+		w.Header().Set("X-Limit-"+l.name+"-Limit", strconv.Itoa(l.limit))
+		w.Header().Set("X-Limit-"+l.name+"-Remaining", strconv.Itoa(l.limit-q.used))
+		w.Header().Set("X-Limit-"+l.name+"-Reset", strconv.Itoa(reset))
+	}
+
+	if exceeded {
+		w.Header().Set("Retry-After", strconv.Itoa(int((retryAfter+time.Second-1)/time.Second)))
+		writeError(w, http.StatusForbidden, 0, "Rate limit exceeded.")
+		return false
+	}
+
+	return true
+}
+
+func (s *Server) handleOAuthRequest(w http.ResponseWriter, r *http.Request) {
+	params, err := readParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 0, err.Error())
+		return
+	}
+
+	if !s.authenticate(w, params, false) {
+		return
+	}
+
+	redirectURI := param(params, "redirect_uri")
+	if redirectURI == "" {
+		writeError(w, http.StatusBadRequest, 140, "Missing redirect url.")
+		return
+	}
+
+	s.mu.Lock()
+	s.requestToken++
+	code := fmt.Sprintf("request-token-%d", s.requestToken)
+	s.auths[code] = &pendingAuth{redirectURI: redirectURI}
+	s.mu.Unlock()
+
+	writeJSON(w, map[string]string{"code": code})
+}
+
+// handleUserAuthorize emulates the page where user approve or reject the app
+func (s *Server) handleUserAuthorize(w http.ResponseWriter, r *http.Request) {
+	code := r.URL.Query().Get("request_token")
+	redirectURI := r.URL.Query().Get("redirect_uri")
+
+	s.mu.Lock()
+	auth, ok := s.auths[code]
+	if ok {
+		auth.approved = !s.reject
+		auth.rejected = s.reject
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		http.Error(w, "invalid request token", http.StatusBadRequest)
+		return
+	}
+
+	if redirectURI == "" {
+		redirectURI = auth.redirectURI
+	}
+	http.Redirect(w, r, redirectURI, http.StatusFound)
+}
+
+func (s *Server) handleOAuthAuthorize(w http.ResponseWriter, r *http.Request) {
+	params, err := readParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 0, err.Error())
+		return
+	}
+
+	if !s.authenticate(w, params, false) {
+		return
+	}
+
+	code := param(params, "code")
+	if code == "" {
+		writeError(w, http.StatusBadRequest, 182, "Missing code.")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	auth, ok := s.auths[code]
+	switch {
+	case !ok:
+		writeError(w, http.StatusBadRequest, 181, "Invalid redirect uri.")
+	case auth.used:
+		writeError(w, http.StatusForbidden, 159, "Already used code.")
+	case !auth.approved:
+		writeError(w, http.StatusForbidden, 158, "User rejected code.")
+	default:
+		auth.used = true
+		accessToken := fmt.Sprintf("access-token-%s", code)
+		s.accessTokens[accessToken] = Username
+		s.issued = append(s.issued, accessToken)
+		writeJSON(w, map[string]string{"access_token": accessToken, "username": Username})
+	}
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	params, err := readParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 0, err.Error())
+		return
+	}
+
+	if !s.authenticate(w, params, true) {
+		return
+	}
+
+	state := param(params, "state")
+	favorite := param(params, "favorite")
+	tag := param(params, "tag")
+	contentType := param(params, "contentType")
+	search := strings.ToLower(param(params, "search"))
+	domain := param(params, "domain")
+	detailType := param(params, "detailType")
+	since, _ := strconv.ParseInt(param(params, "since"), 10, 64)
+	count, _ := strconv.Atoi(param(params, "count"))
+	offset, _ := strconv.Atoi(param(params, "offset"))
+
+	s.mu.Lock()
+	items := make([]Item, 0, len(s.items))
+	for _, item := range s.items {
+		updated, _ := strconv.ParseInt(item.TimeUpdated, 10, 64)
+		if since > 0 && updated < since {
+			continue
+		}
+
+		// deleted items are only reported to delta sync
+		if item.Status == "2" {
+			if since > 0 {
+				items = append(items, Item{ItemID: item.ItemID, Status: "2"})
+			}
+			continue
+		}
+
+		if !matchItem(item, state, favorite, tag, contentType, search, domain) {
+			continue
+		}
+
+		v := item.clone()
+		if detailType != "complete" {
+			v.Tags = nil
+			v.Authors = nil
+			v.DomainMetadata = nil
+		}
+		items = append(items, v)
+	}
+	if s.maxCount > 0 && (count == 0 || count > s.maxCount) {
+		count = s.maxCount
+	}
+	s.mu.Unlock()
+
+	sortItems(items, param(params, "sort"))
+
+	if offset > 0 {
+		if offset > len(items) {
+			offset = len(items)
+		}
+		items = items[offset:]
+	}
+	if count > 0 && count < len(items) {
+		items = items[:count]
+	}
+
+	response := map[string]interface{}{
+		"status":   1,
+		"complete": 1,
+		"since":    time.Now().Unix(),
+	}
+
+	// pocket returns empty array instead of object if there is no items
+	if len(items) == 0 {
+		response["list"] = []interface{}{}
+	} else {
+		list := make(map[string]Item, len(items))
+		for i, item := range items {
+			item.SortID = i
+			list[item.ItemID] = item
+		}
+		response["list"] = list
+	}
+
+	writeJSON(w, response)
+}
+
+func matchItem(item *Item, state, favorite, tag, contentType, search, domain string) bool {
+	switch state {
+	case "unread":
+		if item.Status != "0" {
+			return false
+		}
+	case "archive":
+		if item.Status != "1" {
+			return false
+		}
+	}
+
+	if favorite != "" && item.Favorite != favorite {
+		return false
+	}
+
+	switch tag {
+	case "":
+	case "_untagged_":
+		if len(item.Tags) > 0 {
+			return false
+		}
+	default:
+		if _, ok := item.Tags[tag]; !ok {
+			return false
+		}
+	}
+
+	switch contentType {
+	case "article":
+		if item.IsArticle != "1" {
+			return false
+		}
+	case "video":
+		if item.HasVideo == "0" {
+			return false
+		}
+	case "image":
+		if item.HasImage == "0" {
+			return false
+		}
+	}
+
+	if search != "" &&
+		!strings.Contains(strings.ToLower(item.ResolvedTitle), search) &&
+		!strings.Contains(strings.ToLower(item.GivenTitle), search) &&
+		!strings.Contains(strings.ToLower(item.ResolvedURL), search) &&
+		!strings.Contains(strings.ToLower(item.GivenURL), search) {
+		return false
+	}
+
+	if domain != "" {
+		u, err := url.Parse(item.ResolvedURL)
+		if err != nil || !(u.Hostname() == domain || strings.HasSuffix(u.Hostname(), "."+domain)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sortItems(items []Item, by string) {
+	atoi := func(s string) int64 { v, _ := strconv.ParseInt(s, 10, 64); return v }
+
+	var less func(a, b Item) bool
+	switch by {
+	case "oldest":
+		less = func(a, b Item) bool { return atoi(a.TimeAdded) < atoi(b.TimeAdded) }
+	case "title":
+		less = func(a, b Item) bool { return a.ResolvedTitle < b.ResolvedTitle }
+	case "site":
+		less = func(a, b Item) bool { return a.ResolvedURL < b.ResolvedURL }
+	default: // newest
+		less = func(a, b Item) bool { return atoi(a.TimeAdded) > atoi(b.TimeAdded) }
+	}
+
+	// item id breaks tie so that pagination is stable
+	sort.Slice(items, func(i, j int) bool {
+		if less(items[i], items[j]) {
+			return true
+		}
+		if less(items[j], items[i]) {
+			return false
+		}
+		return items[i].ItemID < items[j].ItemID
+	})
+}
+
+func (s *Server) handleSend(w http.ResponseWriter, r *http.Request) {
+	params, err := readParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 0, err.Error())
+		return
+	}
+
+	if !s.authenticate(w, params, true) {
+		return
+	}
+
+	var actions []Action
+	switch v := params["actions"].(type) {
+	case string:
+		if err := json.Unmarshal([]byte(v), &actions); err != nil {
+			writeError(w, http.StatusBadRequest, 0, "Invalid actions.")
+			return
+		}
+	case []interface{}:
+		buf, _ := json.Marshal(v)
+		if err := json.Unmarshal(buf, &actions); err != nil {
+			writeError(w, http.StatusBadRequest, 0, "Invalid actions.")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	results := make([]interface{}, len(actions))
+	for i, action := range actions {
+		s.actions = append(s.actions, action)
+		results[i] = s.doAction(action)
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, map[string]interface{}{
+		"action_results": results,
+		"status":         1,
+	})
+}
+
+// doAction apply action to items and return its result; caller should hold lock
+func (s *Server) doAction(action Action) interface{} {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+
+	// actions that not belong to a item
+	switch action.Action {
+	case "add":
+		return s.addItem(action)
+	case "tag_rename", "tag_delete":
+		tag := action.Tag
+		if action.Action == "tag_rename" {
+			tag = action.OldTag
+		}
+
+		for _, item := range s.items {
+			if _, ok := item.Tags[tag]; !ok || item.Status == "2" {
+				continue
+			}
+			delete(item.Tags, tag)
+			if action.Action == "tag_rename" {
+				item.Tags[action.NewTag] = Tag{ItemID: item.ItemID, Tag: action.NewTag}
+			}
+			item.TimeUpdated = now
+		}
+		return true
+	}
+
+	item, ok := s.items[action.ItemID]
+	if !ok || item.Status == "2" {
+		// pocket reports success for delete of unknown item
+		return action.Action == "delete"
+	}
+
+	splitTags := func() []string {
+		var tags []string
+		for _, tag := range strings.Split(action.Tags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		return tags
+	}
+
+	switch action.Action {
+	case "delete":
+		item.Status = "2"
+	case "archive":
+		item.Status = "1"
+		item.TimeRead = now
+	case "readd":
+		item.Status = "0"
+		item.TimeRead = "0"
+	case "favorite":
+		item.Favorite = "1"
+		item.TimeFavorited = now
+	case "unfavorite":
+		item.Favorite = "0"
+		item.TimeFavorited = "0"
+	case "tags_add", "tags_replace":
+		if action.Action == "tags_replace" || item.Tags == nil {
+			item.Tags = make(map[string]Tag)
+		}
+		for _, tag := range splitTags() {
+			item.Tags[tag] = Tag{ItemID: item.ItemID, Tag: tag}
+		}
+	case "tags_remove":
+		for _, tag := range splitTags() {
+			delete(item.Tags, tag)
+		}
+	case "tags_clear":
+		item.Tags = nil
+	default:
+		return false
+	}
+
+	item.TimeUpdated = now
+	return true
+}
+
+// addItem add new item from action; caller should hold lock
+func (s *Server) addItem(action Action) Item {
+	item := Item{
+		GivenURL:   action.URL,
+		GivenTitle: action.Title,
+	}
+	if action.Tags != "" {
+		item.Tags = make(map[string]Tag)
+		for _, tag := range strings.Split(action.Tags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				item.Tags[tag] = Tag{Tag: tag}
+			}
+		}
+	}
+	s.fillItem(&item)
+	s.items[item.ItemID] = &item
+
+	return item.clone()
+}
+
+func (s *Server) handleAdd(w http.ResponseWriter, r *http.Request) {
+	params, err := readParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 0, err.Error())
+		return
+	}
+
+	if !s.authenticate(w, params, true) {
+		return
+	}
+
+	action := Action{
+		Action: "add",
+		URL:    param(params, "url"),
+		Title:  param(params, "title"),
+		Tags:   param(params, "tags"),
+		RefID:  param(params, "tweet_id"),
+	}
+	if action.URL == "" {
+		writeError(w, http.StatusBadRequest, 0, "Missing url.")
+		return
+	}
+
+	s.mu.Lock()
+	s.actions = append(s.actions, action)
+	item := s.addItem(action)
+	s.mu.Unlock()
+
+	writeJSON(w, map[string]interface{}{
+		"item":   addedItem(item),
+		"status": 1,
+	})
+}
+
+// addedItem encode item as /v3/add does; empty collections are encoded as []
+func addedItem(item Item) map[string]interface{} {
+	buf, _ := json.Marshal(item)
+
+	var v map[string]interface{}
+	json.Unmarshal(buf, &v)
+	for _, key := range []string{"tags", "authors", "images", "videos"} {
+		if _, ok := v[key]; !ok {
+			v[key] = []interface{}{}
+		}
+	}
+
+	return v
+}
@@ -0,0 +1,115 @@
+package pockettest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/whitekid/go-utils/request"
+)
+
+func get(t *testing.T, s *Server, params map[string]interface{}) (*request.Response, map[string]Item) {
+	params["consumer_key"] = ConsumerKey
+	params["access_token"] = AccessToken
+
+	resp, err := request.Post(s.APIURL() + "/get").JSON(params).Do()
+	require.NoError(t, err)
+	if !resp.Success() {
+		return resp, nil
+	}
+
+	var response struct {
+		List map[string]Item `json:"list"`
+	}
+	resp.JSON(&response)
+	return resp, response.List
+}
+
+func TestGet(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	s.AddItems(
+		Item{GivenURL: "https://golang.org/", Favorite: "1", Tags: map[string]Tag{"go": {}}},
+		Item{GivenURL: "https://rust-lang.org/", Status: "1"},
+		Item{GivenURL: "https://blog.golang.org/", HasVideo: "1"},
+	)
+
+	type args struct {
+		params map[string]interface{}
+	}
+	tests := [...]struct {
+		name      string
+		args      args
+		wantCount int
+	}{
+		{"all", args{map[string]interface{}{}}, 3},
+		{"favorite", args{map[string]interface{}{"favorite": "1"}}, 1},
+		{"unread", args{map[string]interface{}{"state": "unread"}}, 2},
+		{"archive", args{map[string]interface{}{"state": "archive"}}, 1},
+		{"tag", args{map[string]interface{}{"tag": "go"}}, 1},
+		{"untagged", args{map[string]interface{}{"tag": "_untagged_"}}, 2},
+		{"video", args{map[string]interface{}{"contentType": "video"}}, 1},
+		{"domain", args{map[string]interface{}{"domain": "golang.org"}}, 2},
+		{"search", args{map[string]interface{}{"search": "rust"}}, 1},
+		{"count", args{map[string]interface{}{"count": 2, "offset": 2}}, 1},
+		{"empty", args{map[string]interface{}{"search": "not-found"}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, items := get(t, s, tt.args.params)
+			require.True(t, resp.Success(), "status=%d", resp.StatusCode)
+			require.Equal(t, tt.wantCount, len(items))
+		})
+	}
+}
+
+func TestGetMaxCount(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	s.AddItems(Item{GivenURL: "https://golang.org/"}, Item{GivenURL: "https://go.dev/"}, Item{GivenURL: "https://rust-lang.org/"})
+	s.SetMaxCount(2)
+
+	// fewer items than count are returned
+	_, items := get(t, s, map[string]interface{}{"count": 3})
+	require.Equal(t, 2, len(items))
+	_, items = get(t, s, map[string]interface{}{})
+	require.Equal(t, 2, len(items))
+	_, items = get(t, s, map[string]interface{}{"count": 1})
+	require.Equal(t, 1, len(items))
+}
+
+func TestFault(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	s.InjectFault(Fault{Path: "/v3/get", StatusCode: http.StatusTooManyRequests, ErrorCode: 199, Error: "rate limited", Times: 1})
+	resp, _ := get(t, s, map[string]interface{}{})
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, "199", resp.Header.Get("X-Error-Code"))
+	require.Equal(t, "rate limited", resp.Header.Get("X-Error"))
+
+	// fault applied only once
+	resp, _ = get(t, s, map[string]interface{}{})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	s.InjectFault(Fault{Delay: time.Millisecond * 200, Times: 1})
+	start := time.Now()
+	resp, _ = get(t, s, map[string]interface{}{})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.True(t, time.Since(start) >= time.Millisecond*200)
+	require.Equal(t, 3, s.RequestCount("/v3/get"))
+}
+
+func TestInvalidAccessToken(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	resp, err := request.Post(s.APIURL() + "/get").
+		JSON(map[string]string{"consumer_key": ConsumerKey, "access_token": "invalid"}).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	require.Equal(t, "107", resp.Header.Get("X-Error-Code"))
+}
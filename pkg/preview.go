@@ -0,0 +1,160 @@
+package pocket
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gorilla/securecookie"
+	"github.com/labstack/echo/v4"
+	"github.com/whitekid/go-utils/log"
+)
+
+// formCSRFToken form field of csrf token of preview actions
+const formCSRFToken = "csrf_token"
+
+// previewAction action of preview page
+type previewAction struct {
+	Name  string
+	Label string
+}
+
+// actions of preview page; posted to /article/:item_id/:action
+var previewActions = []previewAction{
+	{ActionArchive, "Archive"},
+	{ActionUnfavorite, "Unfavorite"},
+	{ActionDelete, "Delete"},
+}
+
+// previewData data of preview.html
+type previewData struct {
+	Article     Article
+	Title       string
+	Domain      string
+	Minutes     int
+	Tags        []string
+	ReadURL     string
+	OriginalURL string
+	PickURL     string // pick another with the same query
+	ActionURL   string
+	Query       string
+	CSRFToken   string
+	Actions     []previewAction
+}
+
+// showPreview return true if preview page is requested by preview parameter or enabled by config
+func (s *pocketService) showPreview(c echo.Context) bool {
+	if v := c.QueryParam("preview"); v != "" {
+		preview, err := strconv.ParseBool(v)
+		if err == nil {
+			return preview
+		}
+	}
+
+	return s.preview
+}
+
+// renderPreview render preview page of picked article
+func (s *pocketService) renderPreview(c echo.Context, article Article) error {
+	title := article.ResolvedTitle
+	if title == "" {
+		title = article.GivenTitle
+	}
+	originalURL := article.ResolvedURL
+	if originalURL == "" {
+		originalURL = article.GivenURL
+	}
+	if title == "" {
+		title = originalURL
+	}
+
+	query := c.QueryParams().Encode()
+
+	return c.Render(http.StatusOK, "preview.html", &previewData{
+		Article:     article,
+		Title:       title,
+		Domain:      article.Domain(),
+		Minutes:     minutesToRead(article),
+		Tags:        article.TagNames(),
+		ReadURL:     readURL(article),
+		OriginalURL: originalURL,
+		PickURL:     s.pickURL(query),
+		ActionURL:   fmt.Sprintf("%s/article/%s", s.rootURL, url.PathEscape(article.ItemID)),
+		Query:       query,
+		CSRFToken:   s.csrfToken(c),
+		Actions:     previewActions,
+	})
+}
+
+// csrfToken return csrf token of the session, create one if not exists
+func (s *pocketService) csrfToken(c echo.Context) string {
+	sess := s.session(c)
+	if token, ok := sess.Values[keyCSRFToken].(string); ok && token != "" {
+		return token
+	}
+
+	token := hex.EncodeToString(securecookie.GenerateRandomKey(32))
+	sess.Values[keyCSRFToken] = token
+	sess.Save(c.Request(), c.Response())
+	return token
+}
+
+// validCSRFToken return true if csrf token of the form matches the token of the session
+func (s *pocketService) validCSRFToken(c echo.Context) bool {
+	token, _ := s.session(c).Values[keyCSRFToken].(string)
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(c.FormValue(formCSRFToken))) == 1
+}
+
+// pickURL url of index with query
+func (s *pocketService) pickURL(query string) string {
+	if query == "" {
+		return s.rootURL
+	}
+	return s.rootURL + "/?" + query
+}
+
+// handlePostArticleAction archive, unfavorite or delete article from preview page then pick another
+func (s *pocketService) handlePostArticleAction(c echo.Context) error {
+	itemID := c.Param("item_id")
+
+	var accessToken string
+	if err := s.requireAccessToken(c, &accessToken); err != nil {
+		return c.Redirect(http.StatusFound, s.rootURL)
+	}
+
+	if !s.validCSRFToken(c) {
+		return echo.NewHTTPError(http.StatusForbidden, "invalid csrf token")
+	}
+
+	api := s.newAPI(accessToken).Articles
+	ctx := c.Request().Context()
+
+	var err error
+	switch c.Param("action") {
+	case ActionArchive:
+		err = api.Archive(ctx, itemID)
+	case ActionUnfavorite:
+		err = api.Unfavorite(ctx, itemID)
+	case ActionDelete:
+		err = api.Delete(ctx, itemID)
+	default:
+		return echo.NewHTTPError(http.StatusNotFound, "unknown action")
+	}
+
+	if err != nil {
+		log.Errorf("%s %s failed: %s", c.Param("action"), itemID, err)
+		return s.handleAPIError(c, err)
+	}
+	s.articleChanged(accessToken, itemID, c.Param("action"))
+
+	// only query is taken from the form, so it could not redirect to other sites
+	query, err := url.ParseQuery(c.FormValue("query"))
+	if err != nil {
+		query = nil
+	}
+
+	return c.Redirect(http.StatusSeeOther, s.pickURL(query.Encode()))
+}
@@ -0,0 +1,113 @@
+package pocket
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/whitekid/go-utils/request"
+	"github.com/whitekid/pocket-pick/pkg/pockettest"
+)
+
+func TestPreview(t *testing.T) {
+	ts, pocketServer, teardown := newTestServer()
+	defer teardown()
+
+	ids := pocketServer.AddItems(pockettest.Item{
+		GivenURL:      "https://blog.golang.org/go1.16",
+		ResolvedTitle: "Go 1.16 is released",
+		Excerpt:       "Today the Go team is very happy to announce the release of Go 1.16.",
+		TopImageURL:   "https://blog.golang.org/go1.16.png",
+		WordCount:     "420",
+		Favorite:      "1",
+		Tags:          map[string]pockettest.Tag{"go": {Tag: "go"}},
+	})
+
+	sess := request.NewSession(nil)
+	resp := followRedirect(t, sess, ts.URL, ts.URL, pocketServer.URL)
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	resp, err := sess.Get("%s/?preview=true&tag=go", ts.URL).FollowRedirect(false).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html"))
+
+	buf, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	body := string(buf)
+	for _, want := range []string{
+		"Go 1.16 is released",
+		"Today the Go team is very happy",
+		"https://blog.golang.org/go1.16.png",
+		"blog.golang.org",
+		"420 words",
+		"3 min read",
+		"<span>go</span>",
+		"https://app.getpocket.com/read/" + ids[0],
+		`href="https://blog.golang.org/go1.16"`,
+		`href="` + ts.URL + `/?preview=true&amp;tag=go"`,
+		`action="` + ts.URL + "/article/" + ids[0] + `/archive"`,
+	} {
+		require.Contains(t, body, want)
+	}
+
+	match := regexp.MustCompile(`name="csrf_token" value="([0-9a-f]+)"`).FindStringSubmatch(body)
+	require.Len(t, match, 2)
+	csrfToken := match[1]
+
+	// actions require csrf token of the session
+	for _, token := range []string{"", "invalid"} {
+		form := url.Values{"query": {"preview=true&tag=go"}, "csrf_token": {token}}
+		resp, err = sess.Post("%s/article/%s/archive", ts.URL, ids[0]).
+			Header("Content-Type", "application/x-www-form-urlencoded").
+			Body(strings.NewReader(form.Encode())).
+			FollowRedirect(false).Do()
+		require.NoError(t, err)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	}
+	item, ok := pocketServer.Item(ids[0])
+	require.True(t, ok)
+	require.Equal(t, "0", item.Status)
+
+	// archive and pick another with the same query
+	form := url.Values{"query": {"preview=true&tag=go"}, "csrf_token": {csrfToken}}
+	resp, err = sess.Post("%s/article/%s/archive", ts.URL, ids[0]).
+		Header("Content-Type", "application/x-www-form-urlencoded").
+		Body(strings.NewReader(form.Encode())).
+		FollowRedirect(false).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSeeOther, resp.StatusCode)
+	require.Equal(t, ts.URL+"/?preview=true&tag=go", resp.Header.Get("Location"))
+
+	item, ok = pocketServer.Item(ids[0])
+	require.True(t, ok)
+	require.Equal(t, "1", item.Status)
+
+	form = url.Values{"csrf_token": {csrfToken}}
+	resp, err = sess.Post("%s/article/%s/read", ts.URL, ids[0]).
+		Header("Content-Type", "application/x-www-form-urlencoded").
+		Body(strings.NewReader(form.Encode())).
+		FollowRedirect(false).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestPreviewDisabled(t *testing.T) {
+	ts, pocketServer, teardown := newTestServer(func(s *pocketService) { s.preview = true })
+	defer teardown()
+
+	ids := pocketServer.AddItems(pockettest.Item{GivenURL: "https://golang.org/", Favorite: "1"})
+
+	sess := request.NewSession(nil)
+	resp := followRedirect(t, sess, ts.URL, ts.URL, pocketServer.URL)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err := sess.Get("%s/?preview=0", ts.URL).FollowRedirect(false).Do()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	require.Equal(t, "https://app.getpocket.com/read/"+ids[0], resp.Header.Get("Location"))
+}
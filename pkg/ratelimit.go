@@ -0,0 +1,207 @@
+package pocket
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/whitekid/go-utils/log"
+	"github.com/whitekid/go-utils/request"
+)
+
+// Quota rate limit quota reported by X-Limit-* headers, see https://getpocket.com/developer/docs/rate-limits
+type Quota struct {
+	Limit     int       // number of calls allowed within the window
+	Remaining int       // number of calls remaining before hitting the limit
+	Reset     time.Time // time when the quota resets
+}
+
+// Exhausted return true if there is no remaining calls until reset
+func (q Quota) Exhausted() bool {
+	return q.Limit > 0 && q.Remaining <= 0 && time.Now().Before(q.Reset)
+}
+
+// RateLimits tracks quota of each user and each consumer key
+// share it between GetPocketAPI with WithRateLimits() to track quota across api instances
+type RateLimits struct {
+	mu    sync.Mutex
+	users map[string]Quota // access token -> quota
+	keys  map[string]Quota // consumer key -> quota
+}
+
+// NewRateLimits create new RateLimits
+func NewRateLimits() *RateLimits {
+	return &RateLimits{
+		users: make(map[string]Quota),
+		keys:  make(map[string]Quota),
+	}
+}
+
+// User return quota of user
+func (r *RateLimits) User(accessToken string) (Quota, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	q, ok := r.users[accessToken]
+	return q, ok
+}
+
+// Key return quota of consumer key
+func (r *RateLimits) Key(consumerKey string) (Quota, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	q, ok := r.keys[consumerKey]
+	return q, ok
+}
+
+// update quota from response headers
+func (r *RateLimits) update(consumerKey, accessToken string, header http.Header) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if q, ok := parseQuota(header, "User"); ok && accessToken != "" {
+		r.users[accessToken] = q
+	}
+
+	if q, ok := parseQuota(header, "Key"); ok {
+		r.keys[consumerKey] = q
+	}
+}
+
+// waitDuration return how long to wait until quota of user or consumer key is available
+func (r *RateLimits) waitDuration(consumerKey, accessToken string) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var wait time.Duration
+	for _, q := range []Quota{r.users[accessToken], r.keys[consumerKey]} {
+		if q.Exhausted() {
+			if d := time.Until(q.Reset); d > wait {
+				wait = d
+			}
+		}
+	}
+
+	return wait
+}
+
+// parseQuota parse X-Limit-{kind}-* headers
+func parseQuota(header http.Header, kind string) (Quota, bool) {
+	limit, err := strconv.Atoi(header.Get("X-Limit-" + kind + "-Limit"))
+	if err != nil {
+		return Quota{}, false
+	}
+
+	remaining, _ := strconv.Atoi(header.Get("X-Limit-" + kind + "-Remaining"))
+	reset, _ := strconv.Atoi(header.Get("X-Limit-" + kind + "-Reset"))
+
+	return Quota{
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     time.Now().Add(time.Duration(reset) * time.Second),
+	}, true
+}
+
+// Quota return quota of current user and consumer key; ok is false if quota was not reported yet
+func (g *GetPocketAPI) Quota() (user Quota, key Quota, ok bool) {
+	user, userOK := g.rateLimits.User(g.accessToken)
+	key, keyOK := g.rateLimits.Key(g.consumerKey)
+	return user, key, userOK || keyOK
+}
+
+// retryable return true if request could be succeeded by retrying later
+func retryable(r *request.Response) bool {
+	switch r.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusForbidden:
+		// pocket returns 403 when rate limited
+		if r.Header.Get("Retry-After") != "" {
+			return true
+		}
+		for _, kind := range []string{"User", "Key"} {
+			if q, ok := parseQuota(r.Header, kind); ok && q.Remaining <= 0 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// retryAfter parse Retry-After header; return 0 if not given
+func retryAfter(r *request.Response) time.Duration {
+	value := r.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t)
+	}
+
+	return 0
+}
+
+// do send request; wait while quota is exhausted and retry with backoff if request was throttled
+func (g *GetPocketAPI) do(ctx context.Context, req *request.Request) (*request.Response, error) {
+	backoff := g.retryBackoff
+
+	for i := 0; ; i++ {
+		if wait := g.rateLimits.waitDuration(g.consumerKey, g.accessToken); wait > 0 {
+			if wait > g.maxRetryWait {
+				wait = g.maxRetryWait
+			}
+			log.Infof("quota exhausted, waiting %s", wait)
+			if err := sleep(ctx, wait); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := req.Do()
+		if err != nil {
+			return nil, err
+		}
+
+		g.rateLimits.update(g.consumerKey, g.accessToken, resp.Header)
+
+		if resp.Success() || !retryable(resp) || i >= g.maxRetries {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		wait := backoff
+		if d := retryAfter(resp); d > wait {
+			wait = d
+		}
+		if wait > g.maxRetryWait {
+			wait = g.maxRetryWait
+		}
+
+		log.Infof("request failed with status %d, retry %d in %s", resp.StatusCode, i+1, wait)
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+		backoff *= 2
+	}
+}
+
+// sleep wait for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,111 @@
+package pocket
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/whitekid/pocket-pick/pkg/pockettest"
+)
+
+func TestRetry(t *testing.T) {
+	type args struct {
+		fault pockettest.Fault
+	}
+	tests := [...]struct {
+		name         string
+		args         args
+		wantErr      bool
+		wantRequests int
+	}{
+		{"unavailable", args{pockettest.Fault{StatusCode: http.StatusServiceUnavailable, Times: 2}}, false, 3},
+		{"too many requests", args{pockettest.Fault{StatusCode: http.StatusTooManyRequests, Times: 1}}, false, 2},
+		{"throttled", args{pockettest.Fault{StatusCode: http.StatusForbidden, Header: http.Header{"Retry-After": {"0"}}, Times: 1}}, false, 2},
+		{"exceed retries", args{pockettest.Fault{StatusCode: http.StatusServiceUnavailable}}, true, 4},
+		{"not retryable", args{pockettest.Fault{StatusCode: http.StatusBadRequest}}, true, 1},
+		{"forbidden", args{pockettest.Fault{StatusCode: http.StatusForbidden, ErrorCode: 152}}, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pocketServer := pockettest.NewServer()
+			defer pocketServer.Close()
+
+			tt.args.fault.Path = "/v3/get"
+			pocketServer.InjectFault(tt.args.fault)
+
+			api := newTestAPI(pocketServer, pockettest.AccessToken)
+			_, err := api.Articles.Get(context.Background())
+			require.Equal(t, tt.wantErr, err != nil, "error=%v", err)
+			require.Equal(t, tt.wantRequests, pocketServer.RequestCount("/v3/get"))
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	pocketServer := pockettest.NewServer()
+	defer pocketServer.Close()
+
+	pocketServer.InjectFault(pockettest.Fault{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": {"1"}},
+		Times:      1,
+	})
+
+	api := newTestAPI(pocketServer, pockettest.AccessToken, WithMaxRetryWait(time.Second*2))
+	start := time.Now()
+	_, err := api.Articles.Get(context.Background())
+	require.NoError(t, err)
+	require.True(t, time.Since(start) >= time.Second, "should wait for Retry-After")
+}
+
+func TestQuota(t *testing.T) {
+	pocketServer := pockettest.NewServer()
+	defer pocketServer.Close()
+	pocketServer.SetRateLimit(pockettest.RateLimit{UserLimit: 5, KeyLimit: 10})
+
+	rateLimits := NewRateLimits()
+	api := newTestAPI(pocketServer, pockettest.AccessToken, WithRateLimits(rateLimits))
+
+	_, _, ok := api.Quota()
+	require.False(t, ok)
+
+	for i := 0; i < 2; i++ {
+		_, err := api.Articles.Get(context.Background())
+		require.NoError(t, err)
+	}
+
+	user, key, ok := api.Quota()
+	require.True(t, ok)
+	require.Equal(t, 5, user.Limit)
+	require.Equal(t, 3, user.Remaining)
+	require.Equal(t, 10, key.Limit)
+	require.Equal(t, 8, key.Remaining)
+	require.True(t, user.Reset.After(time.Now()))
+
+	// quota is shared between api instances
+	other := newTestAPI(pocketServer, pockettest.AccessToken, WithRateLimits(rateLimits))
+	otherUser, _, ok := other.Quota()
+	require.True(t, ok)
+	require.Equal(t, user, otherUser)
+}
+
+func TestQuotaExhausted(t *testing.T) {
+	pocketServer := pockettest.NewServer()
+	defer pocketServer.Close()
+	pocketServer.SetRateLimit(pockettest.RateLimit{UserLimit: 1, Window: time.Second})
+
+	api := newTestAPI(pocketServer, pockettest.AccessToken, WithMaxRetryWait(time.Second*2))
+	_, err := api.Articles.Get(context.Background())
+	require.NoError(t, err)
+
+	user, _, _ := api.Quota()
+	require.True(t, user.Exhausted())
+
+	// wait until quota is reset instead of failing
+	start := time.Now()
+	_, err = api.Articles.Get(context.Background())
+	require.NoError(t, err)
+	require.True(t, time.Since(start) >= time.Millisecond*500)
+}
@@ -0,0 +1,56 @@
+package pocket
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"path"
+
+	"github.com/labstack/echo/v4"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// layoutTemplate defines header and footer used by pages
+const layoutTemplate = "templates/layout.html"
+
+// templateRenderer render pages embedded in binary; implements echo.Renderer
+type templateRenderer struct {
+	pages map[string]*template.Template // page name such as "preview.html" -> template
+}
+
+func newTemplateRenderer() (*templateRenderer, error) {
+	files, err := fs.Glob(templateFS, "templates/*.html")
+	if err != nil {
+		return nil, err
+	}
+
+	r := &templateRenderer{pages: make(map[string]*template.Template)}
+	for _, file := range files {
+		if file == layoutTemplate {
+			continue
+		}
+
+		name := path.Base(file)
+		tmpl, err := template.New(name).ParseFS(templateFS, layoutTemplate, file)
+		if err != nil {
+			return nil, err
+		}
+		r.pages[name] = tmpl
+	}
+
+	return r, nil
+}
+
+// Render render page with data
+func (r *templateRenderer) Render(w io.Writer, name string, data interface{}, c echo.Context) error {
+	tmpl, ok := r.pages[name]
+	if !ok {
+		return fmt.Errorf("template not found: %s", name)
+	}
+
+	return tmpl.ExecuteTemplate(w, name, data)
+}
@@ -0,0 +1,349 @@
+package pocket
+
+import (
+	"encoding/base32"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	"github.com/pkg/errors"
+	"github.com/whitekid/go-utils/log"
+	"github.com/whitekid/pocket-pick/pkg/config"
+)
+
+// session stores
+const (
+	SessionStoreCookie = "cookie" // values are encoded in the cookie
+	SessionStoreMemory = "memory" // values are kept in memory, cookie has only session id
+	SessionStoreFile   = "file"   // values are kept in files, cookie has only session id
+)
+
+// newSessionStoreFromConfig create session store with config
+func newSessionStoreFromConfig() (sessions.Store, error) {
+	keyPairs, err := parseSessionKeys(config.SessionKeys())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keyPairs) == 0 {
+		log.Warn("session keys are not configured, sessions will be invalidated on restart")
+		keyPairs = [][]byte{securecookie.GenerateRandomKey(64), securecookie.GenerateRandomKey(32)}
+	}
+
+	sameSite, err := parseSameSite(config.SessionSameSite())
+	if err != nil {
+		return nil, err
+	}
+	if sameSite == http.SameSiteNoneMode && !config.SessionSecure() {
+		return nil, errors.New("SameSite=none requires secure session cookie")
+	}
+
+	options := sessions.Options{
+		Path:     "/",
+		MaxAge:   int(config.SessionMaxAge() / time.Second),
+		Secure:   config.SessionSecure(),
+		HttpOnly: true,
+		SameSite: sameSite,
+	}
+
+	return newSessionStore(config.SessionStore(), config.SessionDir(), options, keyPairs...)
+}
+
+// newSessionStore create session store of kind
+func newSessionStore(kind string, dir string, options sessions.Options, keyPairs ...[]byte) (sessions.Store, error) {
+	switch kind {
+	case SessionStoreCookie, "":
+		store := sessions.NewCookieStore(keyPairs...)
+		store.Options = &options
+		store.MaxAge(options.MaxAge)
+		return store, nil
+
+	case SessionStoreFile:
+		if dir == "" {
+			dir = filepath.Join(os.TempDir(), "pocket-pick-sessions")
+		}
+		return newFileStore(dir, options, keyPairs...)
+
+	case SessionStoreMemory:
+		return newMemoryStore(options, keyPairs...), nil
+	}
+
+	return nil, fmt.Errorf("unknown session store: %s", kind)
+}
+
+// parseSessionKeys parse session keys as hashKey[:blockKey] to key pairs
+// block key should be 16, 24 or 32 bytes to select AES-128, AES-192, or AES-256
+func parseSessionKeys(keys []string) ([][]byte, error) {
+	keyPairs := make([][]byte, 0, len(keys)*2)
+	for _, key := range keys {
+		parts := strings.SplitN(key, ":", 2)
+		if parts[0] == "" {
+			return nil, errors.New("empty session hash key")
+		}
+
+		var blockKey []byte
+		if len(parts) == 2 {
+			blockKey = []byte(parts[1])
+			switch len(blockKey) {
+			case 16, 24, 32:
+			default:
+				return nil, fmt.Errorf("invalid session block key length %d, should be 16, 24 or 32", len(blockKey))
+			}
+		}
+
+		keyPairs = append(keyPairs, []byte(parts[0]), blockKey)
+	}
+
+	return keyPairs, nil
+}
+
+func parseSameSite(value string) (http.SameSite, error) {
+	switch strings.ToLower(value) {
+	case "", "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	}
+
+	return 0, fmt.Errorf("invalid SameSite: %s", value)
+}
+
+// sessionCleanWindow interval to remove expired sessions of memory and file store
+const sessionCleanWindow = time.Minute
+
+// memoryStore keeps session values in memory; the cookie has only signed session id
+type memoryStore struct {
+	codecs  []securecookie.Codec
+	options sessions.Options
+
+	mu       sync.Mutex
+	sessions map[string]memorySession // session id -> values
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+type memorySession struct {
+	values  map[interface{}]interface{}
+	expires time.Time // zero if session has no max age
+}
+
+func (m memorySession) expired(now time.Time) bool {
+	return !m.expires.IsZero() && m.expires.Before(now)
+}
+
+func newMemoryStore(options sessions.Options, keyPairs ...[]byte) *memoryStore {
+	codecs := securecookie.CodecsFromPairs(keyPairs...)
+	for _, codec := range codecs {
+		if c, ok := codec.(*securecookie.SecureCookie); ok {
+			c.MaxAge(options.MaxAge)
+		}
+	}
+
+	s := &memoryStore{
+		codecs:   codecs,
+		options:  options,
+		sessions: make(map[string]memorySession),
+		done:     make(chan struct{}),
+	}
+	go s.cleaner(sessionCleanWindow)
+
+	return s
+}
+
+// Close stop removing expired sessions
+func (s *memoryStore) Close() error {
+	s.closeOnce.Do(func() { close(s.done) })
+	return nil
+}
+
+// cleaner remove expired sessions periodically until store is closed
+func (s *memoryStore) cleaner(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.sweep(time.Now())
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// sweep remove sessions expired before now
+func (s *memoryStore) sweep(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, stored := range s.sessions {
+		if stored.expired(now) {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+// Get return cached session of request
+func (s *memoryStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New create session and load values if session cookie is valid
+func (s *memoryStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	options := s.options
+	session.Options = &options
+	session.IsNew = true
+
+	c, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+
+	if err := securecookie.DecodeMulti(name, c.Value, &session.ID, s.codecs...); err != nil {
+		session.ID = ""
+		return session, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.sessions[session.ID]
+	if !ok || stored.expired(time.Now()) {
+		delete(s.sessions, session.ID)
+		return session, nil
+	}
+
+	for k, v := range stored.values {
+		session.Values[k] = v
+	}
+	session.IsNew = false
+
+	return session, nil
+}
+
+// Save save session values and write session id cookie; remove session if MaxAge < 0
+func (s *memoryStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session.Options.MaxAge < 0 {
+		delete(s.sessions, session.ID)
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	if session.ID == "" {
+		session.ID = strings.TrimRight(base32.StdEncoding.EncodeToString(securecookie.GenerateRandomKey(32)), "=")
+	}
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.codecs...)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[interface{}]interface{}, len(session.Values))
+	for k, v := range session.Values {
+		values[k] = v
+	}
+	stored := memorySession{values: values}
+	if session.Options.MaxAge > 0 {
+		stored.expires = time.Now().Add(time.Duration(session.Options.MaxAge) * time.Second)
+	}
+	s.sessions[session.ID] = stored
+
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// fileStore filesystem session store which removes files of expired sessions
+type fileStore struct {
+	*sessions.FilesystemStore
+
+	dir    string
+	maxAge time.Duration // zero if session has no max age
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newFileStore(dir string, options sessions.Options, keyPairs ...[]byte) (*fileStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrapf(err, "create session dir %s", dir)
+	}
+
+	store := sessions.NewFilesystemStore(dir, keyPairs...)
+	store.Options = &options
+	store.MaxAge(options.MaxAge)
+
+	s := &fileStore{
+		FilesystemStore: store,
+		dir:             dir,
+		done:            make(chan struct{}),
+	}
+	if options.MaxAge > 0 {
+		s.maxAge = time.Duration(options.MaxAge) * time.Second
+	}
+	go s.cleaner(sessionCleanWindow)
+
+	return s, nil
+}
+
+// Close stop removing expired sessions
+func (s *fileStore) Close() error {
+	s.closeOnce.Do(func() { close(s.done) })
+	return nil
+}
+
+// cleaner remove expired session files periodically until store is closed
+func (s *fileStore) cleaner(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.sweep(time.Now()); err != nil {
+				log.Errorf("sweep expired sessions failed: %s", err)
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// sweep remove session files which are not saved within max age before now
+func (s *fileStore) sweep(now time.Time) error {
+	if s.maxAge == 0 {
+		return nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(s.dir, "session_*"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+
+		if now.Sub(info.ModTime()) > s.maxAge {
+			if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+				return errors.Wrapf(err, "remove %s", file)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,201 @@
+package pocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"github.com/whitekid/go-utils/request"
+	"github.com/whitekid/pocket-pick/pkg/pockettest"
+)
+
+func TestParseSessionKeys(t *testing.T) {
+	type args struct {
+		keys []string
+	}
+	tests := [...]struct {
+		name    string
+		args    args
+		want    [][]byte
+		wantErr bool
+	}{
+		{"empty", args{nil}, [][]byte{}, false},
+		{"hash only", args{[]string{"hash"}}, [][]byte{[]byte("hash"), nil}, false},
+		{"hash and block", args{[]string{"hash:0123456789abcdef"}}, [][]byte{[]byte("hash"), []byte("0123456789abcdef")}, false},
+		{"rotation", args{[]string{"new", "old"}}, [][]byte{[]byte("new"), nil, []byte("old"), nil}, false},
+		{"empty hash", args{[]string{":0123456789abcdef"}}, nil, true},
+		{"invalid block", args{[]string{"hash:short"}}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSessionKeys(tt.args.keys)
+			if (err != nil) != tt.wantErr {
+				require.Failf(t, "parseSessionKeys() failed", "error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// sessionRoundTrip save value with store and load it with another store
+func sessionRoundTrip(t *testing.T, save, load sessions.Store) (*sessions.Session, error) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	sess, err := save.Get(req, "test")
+	require.NoError(t, err)
+	sess.Values["key"] = "value"
+	require.NoError(t, sess.Save(req, rec))
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range rec.Result().Cookies() {
+		req.AddCookie(c)
+	}
+
+	return load.Get(req, "test")
+}
+
+func TestMemoryStore(t *testing.T) {
+	options := sessions.Options{Path: "/", MaxAge: 60}
+	store := newMemoryStore(options, []byte("hash"), nil)
+	defer store.Close()
+
+	sess, err := sessionRoundTrip(t, store, store)
+	require.NoError(t, err)
+	require.False(t, sess.IsNew)
+	require.Equal(t, "value", sess.Values["key"])
+
+	// session of other store is not valid
+	other := newMemoryStore(options, []byte("other"), nil)
+	defer other.Close()
+	sess, err = sessionRoundTrip(t, store, other)
+	require.Error(t, err)
+	require.True(t, sess.IsNew)
+	require.Nil(t, sess.Values["key"])
+
+	// expired sessions are removed by sweep, not by save
+	_, err = sessionRoundTrip(t, store, store)
+	require.NoError(t, err)
+	require.NotEmpty(t, store.sessions)
+	store.sweep(time.Now().Add(time.Minute + time.Second))
+	require.Empty(t, store.sessions)
+	require.NoError(t, store.Close())
+	require.NoError(t, store.Close(), "close twice")
+}
+
+func TestFileStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sessions")
+	store, err := newFileStore(dir, sessions.Options{Path: "/", MaxAge: 60}, []byte("hash"), nil)
+	require.NoError(t, err)
+	defer store.Close()
+
+	sess, err := sessionRoundTrip(t, store, store)
+	require.NoError(t, err)
+	require.Equal(t, "value", sess.Values["key"])
+
+	files, err := filepath.Glob(filepath.Join(dir, "session_*"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	// files of expired sessions are removed by sweep
+	require.NoError(t, store.sweep(time.Now()))
+	require.FileExists(t, files[0])
+	require.NoError(t, store.sweep(time.Now().Add(time.Minute+time.Second)))
+	require.NoFileExists(t, files[0])
+
+	require.NoError(t, store.Close())
+	require.NoError(t, store.Close(), "close twice")
+}
+
+func TestSessionKeyRotation(t *testing.T) {
+	options := sessions.Options{Path: "/", MaxAge: 60}
+	oldKeys := [][]byte{[]byte("old-hash"), []byte("0123456789abcdef")}
+	newKeys := [][]byte{[]byte("new-hash"), []byte("fedcba9876543210")}
+
+	for _, kind := range []string{SessionStoreCookie, SessionStoreFile} {
+		t.Run(kind, func(t *testing.T) {
+			dir := t.TempDir()
+			oldStore, err := newSessionStore(kind, dir, options, oldKeys...)
+			require.NoError(t, err)
+			rotated, err := newSessionStore(kind, dir, options, append(newKeys, oldKeys...)...)
+			require.NoError(t, err)
+			newStore, err := newSessionStore(kind, dir, options, newKeys...)
+			require.NoError(t, err)
+
+			sess, err := sessionRoundTrip(t, oldStore, rotated)
+			require.NoError(t, err)
+			require.Equal(t, "value", sess.Values["key"])
+
+			_, err = sessionRoundTrip(t, oldStore, newStore)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestSessionStores(t *testing.T) {
+	type args struct {
+		kind string
+	}
+	tests := [...]struct {
+		name string
+		args args
+	}{
+		{"cookie", args{SessionStoreCookie}},
+		{"memory", args{SessionStoreMemory}},
+		{"file", args{SessionStoreFile}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := sessions.Options{Path: "/", MaxAge: 60, HttpOnly: true, Secure: true, SameSite: http.SameSiteStrictMode}
+			store, err := newSessionStore(tt.args.kind, t.TempDir(), options, []byte("hash"), []byte("0123456789abcdef"))
+			require.NoError(t, err)
+
+			ts, pocketServer, teardown := newTestServer(func(s *pocketService) { s.sessionStore = store })
+			defer teardown()
+
+			ids := pocketServer.AddItems(pockettest.Item{GivenURL: "https://blog.golang.org/", Favorite: "1"})
+
+			sess := request.NewSession(nil)
+			resp := followRedirect(t, sess, ts.URL, ts.URL, pocketServer.URL)
+			require.Equal(t, "https://app.getpocket.com/read/"+ids[0], resp.Header.Get("Location"))
+
+			// cookie options
+			resp, err = sess.Get(ts.URL + "/sessions").Do()
+			require.NoError(t, err)
+			cookies := resp.Cookies()
+			require.Equal(t, 1, len(cookies))
+			require.True(t, cookies[0].Secure)
+			require.True(t, cookies[0].HttpOnly)
+			require.Equal(t, http.SameSiteStrictMode, cookies[0].SameSite)
+			require.Equal(t, 60, cookies[0].MaxAge)
+			require.False(t, strings.Contains(cookies[0].Value, pockettest.AccessToken))
+		})
+	}
+}
+
+func TestSessionSameSiteNone(t *testing.T) {
+	viper.Set("session_same_site", "none")
+	defer viper.Set("session_same_site", nil)
+
+	_, err := newSessionStoreFromConfig()
+	require.Error(t, err, "SameSite=none without secure")
+
+	viper.Set("session_secure", true)
+	defer viper.Set("session_secure", nil)
+	_, err = newSessionStoreFromConfig()
+	require.NoError(t, err)
+}
+
+func TestUnknownSessionStore(t *testing.T) {
+	_, err := newSessionStore("unknown", "", sessions.Options{})
+	require.Error(t, err)
+}
@@ -0,0 +1,139 @@
+package pocket
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/whitekid/go-utils/log"
+)
+
+// syncState local copy of articles and since of the last sync
+type syncState struct {
+	Since    int64              `json:"since"`
+	Articles map[string]Article `json:"articles"`
+}
+
+// syncer keeps local copy of articles in cache and fetch only changes since the last sync
+// using since of Retrieve API; articles are fetched with complete detail to keep tags
+type syncer struct {
+	cache  cacher
+	ttl    time.Duration      // ttl of sync state from the last sync; never expires if zero
+	opts   []GetOption        // options for the first full sync
+	filter func(Article) bool // articles to keep; changed articles that not matched are removed
+}
+
+func newSyncer(cache cacher, ttl time.Duration, filter func(Article) bool, opts ...GetOption) *syncer {
+	return &syncer{
+		cache:  cache,
+		ttl:    ttl,
+		opts:   opts,
+		filter: filter,
+	}
+}
+
+// newFavoriteSyncer syncer for favorite articles
+func newFavoriteSyncer(cache cacher, ttl time.Duration) *syncer {
+	return newSyncer(cache, ttl, func(a Article) bool { return a.Favorite }, WithFavorate(Favorited))
+}
+
+// Sync sync articles of api user and return local copy; sync state is kept in cache with key
+func (s *syncer) Sync(ctx context.Context, api *GetPocketAPI, key string) (map[string]Article, error) {
+	state, ok := s.load(key)
+
+	var err error
+	if ok {
+		err = s.delta(ctx, api, state)
+	} else {
+		state, err = s.full(ctx, api)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.save(key, state); err != nil {
+		return nil, errors.Wrap(err, "save sync state")
+	}
+
+	return state.Articles, nil
+}
+
+// full fetch all articles
+func (s *syncer) full(ctx context.Context, api *GetPocketAPI) (*syncState, error) {
+	state := &syncState{Articles: make(map[string]Article)}
+
+	it := api.Articles.Iterate(ctx, 0, append([]GetOption{WithDetailType(DetailTypeComplete)}, s.opts...)...)
+	for it.Next() {
+		article := it.Article()
+		state.Articles[article.ItemID] = article
+	}
+	if err := it.Err(); err != nil {
+		return nil, errors.Wrap(err, "full sync")
+	}
+	state.Since = it.Since().Unix()
+	log.Debugf("full sync: %d articles, since=%d", len(state.Articles), state.Since)
+
+	return state, nil
+}
+
+// delta fetch articles changed since the last sync and merge them to state
+// changes are fetched without filter so that articles which no longer match the filter are removed
+func (s *syncer) delta(ctx context.Context, api *GetPocketAPI, state *syncState) error {
+	it := api.Articles.Iterate(ctx, 0, WithState(StateAll), WithSince(time.Unix(state.Since, 0)), WithDetailType(DetailTypeComplete))
+
+	changes := 0
+	for it.Next() {
+		article := it.Article()
+		changes++
+
+		if article.Status == ArticleDeleted || (s.filter != nil && !s.filter(article)) {
+			delete(state.Articles, article.ItemID)
+			continue
+		}
+
+		state.Articles[article.ItemID] = article
+	}
+	if err := it.Err(); err != nil {
+		return errors.Wrap(err, "delta sync")
+	}
+
+	if since := it.Since(); !since.IsZero() {
+		state.Since = since.Unix()
+	}
+	log.Debugf("delta sync: %d changes, %d articles, since=%d", changes, len(state.Articles), state.Since)
+
+	return nil
+}
+
+func (s *syncer) load(key string) (*syncState, bool) {
+	data, exists := s.cache.Get([]byte(key))
+	if !exists {
+		return nil, false
+	}
+
+	var state syncState
+	if err := json.Unmarshal(data, &state); err != nil || state.Since == 0 {
+		return nil, false
+	}
+
+	if state.Articles == nil {
+		state.Articles = make(map[string]Article)
+	}
+
+	return &state, true
+}
+
+func (s *syncer) save(key string, state *syncState) error {
+	buf, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	var opts []setOption
+	if s.ttl > 0 {
+		opts = append(opts, withTTL(s.ttl))
+	}
+
+	return s.cache.Set([]byte(key), buf, opts...)
+}
@@ -0,0 +1,96 @@
+package pocket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/whitekid/pocket-pick/pkg/pockettest"
+)
+
+func TestSync(t *testing.T) {
+	pocketServer := pockettest.NewServer()
+	defer pocketServer.Close()
+
+	ids := pocketServer.AddItems(
+		pockettest.Item{GivenURL: "https://golang.org/", Favorite: "1"},
+		pockettest.Item{GivenURL: "https://go.dev/", Favorite: "1"},
+		pockettest.Item{GivenURL: "https://blog.golang.org/", Favorite: "1"},
+		pockettest.Item{GivenURL: "https://rust-lang.org/"},
+	)
+
+	ctx := context.Background()
+	api := newTestAPI(pocketServer, pockettest.AccessToken)
+	cache, err := newBigCache(16)
+	require.NoError(t, err)
+	defer cache.Close()
+	syncer := newFavoriteSyncer(cache, 0)
+
+	articles, err := syncer.Sync(ctx, api, "test/sync")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(articles))
+
+	state, ok := syncer.load("test/sync")
+	require.True(t, ok)
+	require.NotEqual(t, int64(0), state.Since)
+
+	// change items: delete, unfavorite, favorite and add
+	require.NoError(t, api.Articles.Delete(ctx, ids[0]))
+	_, err = api.Articles.sendAction(ctx, []articleActionParam{
+		{Action: "unfavorite", ItemID: ids[1]},
+		{Action: "favorite", ItemID: ids[3]},
+	})
+	require.NoError(t, err)
+	added := pocketServer.AddItems(pockettest.Item{GivenURL: "https://pkg.go.dev/", Favorite: "1"})
+
+	articles, err = syncer.Sync(ctx, api, "test/sync")
+	require.NoError(t, err)
+
+	got := make([]string, 0, len(articles))
+	for id := range articles {
+		got = append(got, id)
+	}
+	require.ElementsMatch(t, []string{ids[2], ids[3], added[0]}, got)
+}
+
+func TestSyncError(t *testing.T) {
+	pocketServer := pockettest.NewServer()
+	defer pocketServer.Close()
+
+	api := newTestAPI(pocketServer, "invalid-token")
+	cache, err := newBigCache(16)
+	require.NoError(t, err)
+	defer cache.Close()
+	syncer := newFavoriteSyncer(cache, 0)
+
+	_, err = syncer.Sync(context.Background(), api, "test/sync")
+	require.Error(t, err)
+
+	_, ok := syncer.load("test/sync")
+	require.False(t, ok, "sync state should not be saved when failed")
+}
+
+func TestSyncTTL(t *testing.T) {
+	pocketServer := pockettest.NewServer()
+	defer pocketServer.Close()
+
+	pocketServer.AddItems(pockettest.Item{GivenURL: "https://golang.org/", Favorite: "1"})
+
+	api := newTestAPI(pocketServer, pockettest.AccessToken)
+	cache, err := newBigCache(16)
+	require.NoError(t, err)
+	defer cache.Close()
+	syncer := newFavoriteSyncer(cache, 50*time.Millisecond)
+
+	_, err = syncer.Sync(context.Background(), api, "test/sync")
+	require.NoError(t, err)
+	_, ok := syncer.load("test/sync")
+	require.True(t, ok)
+
+	// sync state of users who do not come back is removed
+	require.Eventually(t, func() bool {
+		_, ok := syncer.load("test/sync")
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,243 @@
+package pocket
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/whitekid/go-utils/request"
+)
+
+// headerAccessToken header of access token of the user for admin cache routes; not in path to keep it out of logs
+const headerAccessToken = "X-Access-Token"
+
+// errors of user cache
+var (
+	errUnknownCacheEntry  = errors.New("unknown cache entry")
+	errCacheEntryNotFound = errors.New("cache entry not found")
+)
+
+// userCacheKeys cache keys of the user
+func userCacheKeys(accessToken string) []string {
+	return []string{favoritesCacheKey(accessToken), favoritesSyncKey(accessToken), pickCountsKey(accessToken), historyKey(accessToken)}
+}
+
+// CacheEntry cache entry of a user
+type CacheEntry struct {
+	Name string `json:"name"` // name of entry without access token such as favorites
+	Size int    `json:"size"` // size of value in bytes
+}
+
+// userCache cache entries of a user on the cache of the service
+type userCache struct {
+	cache       cacher
+	accessToken string
+}
+
+func newUserCache(cache cacher, accessToken string) *userCache {
+	return &userCache{cache: cache, accessToken: accessToken}
+}
+
+func (u *userCache) key(name string) (string, error) {
+	key := u.accessToken + "/" + name
+	for _, k := range userCacheKeys(u.accessToken) {
+		if k == key {
+			return key, nil
+		}
+	}
+
+	return "", errors.Wrap(errUnknownCacheEntry, name)
+}
+
+func (u *userCache) name(key string) string { return strings.TrimPrefix(key, u.accessToken+"/") }
+
+// Entries return cache entries of the user which exist
+func (u *userCache) Entries() []CacheEntry {
+	entries := []CacheEntry{}
+	for _, key := range userCacheKeys(u.accessToken) {
+		if value, exists := u.cache.Get([]byte(key)); exists {
+			entries = append(entries, CacheEntry{Name: u.name(key), Size: len(value)})
+		}
+	}
+
+	return entries
+}
+
+// Get return value of cache entry of name
+func (u *userCache) Get(name string) ([]byte, error) {
+	key, err := u.key(name)
+	if err != nil {
+		return nil, err
+	}
+
+	value, exists := u.cache.Get([]byte(key))
+	if !exists {
+		return nil, errors.Wrap(errCacheEntryNotFound, name)
+	}
+
+	return value, nil
+}
+
+// Purge remove cache entries of names, or all entries of the user if names are not given
+func (u *userCache) Purge(names ...string) error {
+	keys := userCacheKeys(u.accessToken)
+	if len(names) > 0 {
+		keys = keys[:0:0]
+		for _, name := range names {
+			key, err := u.key(name)
+			if err != nil {
+				return err
+			}
+			keys = append(keys, key)
+		}
+	}
+
+	for _, key := range keys {
+		if err := u.cache.Delete([]byte(key)); err != nil {
+			return errors.Wrapf(err, "delete %s", u.name(key))
+		}
+	}
+
+	return nil
+}
+
+// setupUserCacheRoute setup admin routes for cache entries of the user of access token header
+func (s *pocketService) setupUserCacheRoute(g *echo.Group) {
+	g.GET("/cache/user", s.handleGetUserCache)
+	g.GET("/cache/user/*", s.handleGetUserCacheEntry)
+	g.DELETE("/cache/user", s.handleDeleteUserCache)
+}
+
+func (s *pocketService) userCache(c echo.Context) (*userCache, error) {
+	accessToken := c.Request().Header.Get(headerAccessToken)
+	if accessToken == "" {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, headerAccessToken+" header required")
+	}
+
+	return newUserCache(s.cache, accessToken), nil
+}
+
+// userCacheError convert user cache errors to http errors
+func userCacheError(err error) error {
+	switch {
+	case errors.Is(err, errUnknownCacheEntry):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, errCacheEntryNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+
+	return err
+}
+
+func (s *pocketService) handleGetUserCache(c echo.Context) error {
+	cache, err := s.userCache(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, cache.Entries())
+}
+
+func (s *pocketService) handleGetUserCacheEntry(c echo.Context) error {
+	cache, err := s.userCache(c)
+	if err != nil {
+		return err
+	}
+
+	value, err := cache.Get(c.Param("*"))
+	if err != nil {
+		return userCacheError(err)
+	}
+
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSONCharsetUTF8, value)
+}
+
+func (s *pocketService) handleDeleteUserCache(c echo.Context) error {
+	cache, err := s.userCache(c)
+	if err != nil {
+		return err
+	}
+
+	if err := cache.Purge(c.QueryParams()["name"]...); err != nil {
+		return userCacheError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// UserCacheClient manage cache entries of a user on the running server with admin token
+type UserCacheClient struct {
+	rootURL     string
+	adminToken  string
+	accessToken string
+}
+
+// NewUserCacheClient create client of the server of rootURL for the user of access token
+func NewUserCacheClient(rootURL, adminToken, accessToken string) (*UserCacheClient, error) {
+	if accessToken == "" {
+		return nil, ErrMissingAccessToken
+	}
+	if adminToken == "" {
+		return nil, errors.New("missing admin token")
+	}
+
+	return &UserCacheClient{rootURL: strings.TrimSuffix(rootURL, "/"), adminToken: adminToken, accessToken: accessToken}, nil
+}
+
+func (u *UserCacheClient) do(req *request.Request) (*request.Response, error) {
+	resp, err := req.AuthBearer(u.adminToken).Header(headerAccessToken, u.accessToken).Do()
+	if err != nil {
+		return nil, errors.Wrap(err, "request failed")
+	}
+
+	if !resp.Success() {
+		defer resp.Body.Close()
+		return nil, fmt.Errorf("request failed with %d: %s", resp.StatusCode, strings.TrimSpace(resp.String()))
+	}
+
+	return resp, nil
+}
+
+// Entries return cache entries of the user which exist
+func (u *UserCacheClient) Entries() ([]CacheEntry, error) {
+	resp, err := u.do(request.Get("%s/debug/cache/user", u.rootURL))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var entries []CacheEntry
+	if err := resp.JSON(&entries); err != nil {
+		return nil, errors.Wrap(err, "json decode failed")
+	}
+
+	return entries, nil
+}
+
+// Get return value of cache entry of name
+func (u *UserCacheClient) Get(name string) ([]byte, error) {
+	resp, err := u.do(request.Get("%s/debug/cache/user/%s", u.rootURL, name))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// Purge remove cache entries of names, or all entries of the user if names are not given
+func (u *UserCacheClient) Purge(names ...string) error {
+	req := request.Delete("%s/debug/cache/user", u.rootURL)
+	for _, name := range names {
+		req.Param("name", name)
+	}
+
+	resp, err := u.do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
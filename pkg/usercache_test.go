@@ -0,0 +1,96 @@
+package pocket
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/whitekid/go-utils/request"
+)
+
+func TestUserCache(t *testing.T) {
+	var service *pocketService
+	ts, _, teardown := newTestServer(withAdminToken, func(s *pocketService) { service = s })
+	defer teardown()
+
+	_, err := NewUserCacheClient(ts.URL, testAdminToken, "")
+	require.ErrorIs(t, err, ErrMissingAccessToken)
+	_, err = NewUserCacheClient(ts.URL, "", "token")
+	require.Error(t, err, "missing admin token")
+
+	cache, err := NewUserCacheClient(ts.URL, testAdminToken, "token")
+	require.NoError(t, err)
+
+	require.NoError(t, service.cache.Set([]byte(favoritesCacheKey("token")), []byte(`{"articles":{}}`)))
+	require.NoError(t, service.cache.Set([]byte(favoritesSyncKey("token")), []byte(`{"since":1}`)))
+	require.NoError(t, service.cache.Set([]byte(historyKey("token")), []byte(`{"picks":[]}`)))
+	require.NoError(t, service.cache.Set([]byte(historyKey("other")), []byte(`{"picks":[]}`)))
+
+	entries, err := cache.Entries()
+	require.NoError(t, err)
+	require.Equal(t, []CacheEntry{{Name: "favorites", Size: 15}, {Name: "sync/favorites", Size: 11}, {Name: "history", Size: 12}}, entries)
+
+	value, err := cache.Get("history")
+	require.NoError(t, err)
+	require.Equal(t, `{"picks":[]}`, string(value))
+
+	value, err = cache.Get("sync/favorites")
+	require.NoError(t, err)
+	require.Equal(t, `{"since":1}`, string(value))
+
+	_, err = cache.Get("picks")
+	require.Error(t, err, "not exists")
+	_, err = cache.Get("../other/history")
+	require.Error(t, err, "unknown entry")
+
+	require.NoError(t, cache.Purge("history", "sync/favorites"))
+	entries, err = cache.Entries()
+	require.NoError(t, err)
+	require.Equal(t, []CacheEntry{{Name: "favorites", Size: 15}}, entries)
+
+	require.Error(t, cache.Purge("unknown"))
+	require.NoError(t, cache.Purge())
+	entries, err = cache.Entries()
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	// entries of other users are not purged
+	require.True(t, service.cache.Has([]byte(historyKey("other"))))
+}
+
+func TestUserCacheRoute(t *testing.T) {
+	type args struct {
+		method      string
+		path        string
+		adminToken  string
+		accessToken string
+	}
+	tests := [...]struct {
+		name     string
+		args     args
+		wantCode int
+	}{
+		{"list", args{http.MethodGet, "/debug/cache/user", testAdminToken, "token"}, http.StatusOK},
+		{"no admin token", args{http.MethodGet, "/debug/cache/user", "", "token"}, http.StatusUnauthorized},
+		{"access token as admin token", args{http.MethodGet, "/debug/cache/user", "token", "token"}, http.StatusUnauthorized},
+		{"no access token", args{http.MethodGet, "/debug/cache/user", testAdminToken, ""}, http.StatusBadRequest},
+		{"not found", args{http.MethodGet, "/debug/cache/user/picks", testAdminToken, "token"}, http.StatusNotFound},
+		{"unknown", args{http.MethodGet, "/debug/cache/user/unknown", testAdminToken, "token"}, http.StatusBadRequest},
+		{"purge", args{http.MethodDelete, "/debug/cache/user", testAdminToken, "token"}, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, _, teardown := newTestServer(withAdminToken)
+			defer teardown()
+
+			req := request.New(tt.args.method, ts.URL+tt.args.path).Header(headerAccessToken, tt.args.accessToken)
+			if tt.args.adminToken != "" {
+				req = req.AuthBearer(tt.args.adminToken)
+			}
+			resp, err := req.Do()
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, tt.wantCode, resp.StatusCode)
+		})
+	}
+}